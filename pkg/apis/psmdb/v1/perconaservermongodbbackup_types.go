@@ -2,6 +2,8 @@ package v1
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -17,8 +19,34 @@ type PerconaServerMongoDBBackupSpec struct {
 	Compression      compress.CompressionType `json:"compressionType,omitempty"`
 	CompressionLevel *int                     `json:"compressionLevel,omitempty"`
 
-	// +kubebuilder:validation:Enum={logical,physical}
+	// +kubebuilder:validation:Enum={logical,physical,incremental}
 	Type defs.BackupType `json:"type,omitempty"`
+
+	// IncrementalBase starts a new chain of incremental backups.
+	// It is used only if type is incremental.
+	IncrementalBase bool `json:"incrementalBase,omitempty"`
+
+	// Verify restores the backup into a temporary single-node replica set
+	// after it's completed and runs validate and dbHash checks against the restored data.
+	Verify bool `json:"verify,omitempty"`
+
+	// ExpiresAt is the time after which the backup object is deleted.
+	// The backup data is deleted from the storage as well if the object has percona.com/delete-backup finalizer.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// TTL is the period after the backup object creation after which it's deleted. Can't be used with expiresAt.
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// CopyTo is the list of storages from spec.backup.storages of the cluster
+	// the backup is copied to after it's completed. Filesystem storages are not supported.
+	// Copies are not deleted together with the backup.
+	CopyTo []string `json:"copyTo,omitempty"`
+
+	Hooks *BackupHooks `json:"hooks,omitempty"`
+
+	// Namespaces limits the backup to the given databases and collections
+	// in the "db.*" or "db.collection" format. It's supported only for logical backups.
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 type BackupState string
@@ -33,6 +61,68 @@ const (
 	BackupStateReady     BackupState = "ready"
 )
 
+type BackupVerificationState string
+
+const (
+	BackupVerificationRunning BackupVerificationState = "running"
+	BackupVerificationOK      BackupVerificationState = "verified"
+	BackupVerificationFailed  BackupVerificationState = "verificationFailed"
+)
+
+// BackupCopyStatus describes the copy of the backup in another storage.
+// Destination and storage fields can be used as backupSource of a restore.
+type BackupCopyStatus struct {
+	StorageName string                  `json:"storageName"`
+	State       BackupState             `json:"state,omitempty"`
+	Destination string                  `json:"destination,omitempty"`
+	S3          *BackupStorageS3Spec    `json:"s3,omitempty"`
+	Azure       *BackupStorageAzureSpec `json:"azure,omitempty"`
+	StartAt     *metav1.Time            `json:"start,omitempty"`
+	CompletedAt *metav1.Time            `json:"completed,omitempty"`
+	Error       string                  `json:"error,omitempty"`
+}
+
+type BackupHookState string
+
+const (
+	BackupHookSucceeded BackupHookState = "succeeded"
+	BackupHookFailed    BackupHookState = "failed"
+	BackupHookSkipped   BackupHookState = "skipped"
+)
+
+type BackupHookStatus struct {
+	Name        string          `json:"name"`
+	Phase       BackupHookPhase `json:"phase"`
+	State       BackupHookState `json:"state"`
+	StartAt     *metav1.Time    `json:"start,omitempty"`
+	CompletedAt *metav1.Time    `json:"completed,omitempty"`
+	Output      string          `json:"output,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// BackupVerificationStatus describes the result of restoring the backup
+// into a temporary replica set and checking the restored data.
+type BackupVerificationStatus struct {
+	State BackupVerificationState `json:"state,omitempty"`
+
+	// Cluster is the name of the temporary cluster used to verify the backup.
+	Cluster     string       `json:"cluster,omitempty"`
+	StartAt     *metav1.Time `json:"start,omitempty"`
+	CompletedAt *metav1.Time `json:"completed,omitempty"`
+	Summary     string       `json:"summary,omitempty"`
+
+	// DBHashes contains the md5 returned by dbHash for each restored database.
+	DBHashes map[string]string `json:"dbHashes,omitempty"`
+}
+
+// Finished returns true if verification is completed either way.
+func (v *BackupVerificationStatus) Finished() bool {
+	if v == nil {
+		return false
+	}
+	return v.State == BackupVerificationOK || v.State == BackupVerificationFailed
+}
+
 // PerconaServerMongoDBBackupStatus defines the observed state of PerconaServerMongoDBBackup
 type PerconaServerMongoDBBackupStatus struct {
 	Type           defs.BackupType              `json:"type,omitempty"`
@@ -48,11 +138,39 @@ type PerconaServerMongoDBBackupStatus struct {
 	ReplsetNames   []string                     `json:"replsetNames,omitempty"`
 	PBMname        string                       `json:"pbmName,omitempty"`
 
+	// SrcBackup is the PBM name of the backup this incremental backup is based on.
+	// It is empty for full backups and for the base of an incremental chain.
+	SrcBackup string `json:"srcBackup,omitempty"`
+
+	// Size is the human-readable size of the backup in the storage.
+	Size      string `json:"size,omitempty"`
+	SizeBytes int64  `json:"sizeBytes,omitempty"`
+
+	// ReplsetSizes contains the size of the backup in the storage in bytes for each replset.
+	// It's reported for physical and incremental backups, and for logical backups of a single replset.
+	ReplsetSizes map[string]int64 `json:"replsetSizes,omitempty"`
+
+	// CompressionRatio is the ratio of the uncompressed data size to the size in the storage.
+	// It's reported only for physical and incremental backups.
+	CompressionRatio string `json:"compressionRatio,omitempty"`
+
+	MongoVersion string           `json:"mongodbVersion,omitempty"`
+	FCV          string           `json:"fcv,omitempty"`
+	Duration     *metav1.Duration `json:"duration,omitempty"`
+
 	// Deprecated: Use PBMPods instead
 	PBMPod               string            `json:"pbmPod,omitempty"`
 	PBMPods              map[string]string `json:"pbmPods,omitempty"`
 	Error                string            `json:"error,omitempty"`
 	LatestRestorableTime *metav1.Time      `json:"latestRestorableTime,omitempty"`
+
+	Verification *BackupVerificationStatus `json:"verification,omitempty"`
+	Copies       []BackupCopyStatus        `json:"copies,omitempty"`
+	Hooks        []BackupHookStatus        `json:"hooks,omitempty"`
+
+	// Namespaces is the list of namespaces the backup is limited to.
+	// It's empty if the backup contains all namespaces.
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -67,6 +185,12 @@ type PerconaServerMongoDBBackupStatus struct {
 // +kubebuilder:printcolumn:name="Destination",type=string,JSONPath=".status.destination",description="Backup destination"
 // +kubebuilder:printcolumn:name="Type",type=string,JSONPath=".status.type",description="Backup type"
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=".status.state",description="Job status"
+// +kubebuilder:printcolumn:name="Size",type=string,JSONPath=".status.size",description="Backup size"
+// +kubebuilder:printcolumn:name="Duration",type=string,JSONPath=".status.duration",description="Backup duration",priority=1
+// +kubebuilder:printcolumn:name="MongoDB",type=string,JSONPath=".status.mongodbVersion",description="MongoDB version",priority=1
+// +kubebuilder:printcolumn:name="FCV",type=string,JSONPath=".status.fcv",description="Feature compatibility version",priority=1
+// +kubebuilder:printcolumn:name="Compression Ratio",type=string,JSONPath=".status.compressionRatio",description="Compression ratio",priority=1
+// +kubebuilder:printcolumn:name="Verified",type=string,JSONPath=".status.verification.state",description="Backup verification status",priority=1
 // +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=".status.completed",description="Completed time"
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp",description="Created time"
 type PerconaServerMongoDBBackup struct {
@@ -96,12 +220,139 @@ func (p *PerconaServerMongoDBBackup) CheckFields() error {
 	if string(p.Spec.Type) == "" {
 		p.Spec.Type = defs.LogicalBackup
 	}
+	if p.Spec.IncrementalBase && p.Spec.Type != defs.IncrementalBackup {
+		return fmt.Errorf("spec incrementalBase can be used only with incremental backup type")
+	}
+	if p.Spec.ExpiresAt != nil && p.Spec.TTL != nil {
+		return fmt.Errorf("spec expiresAt and ttl fields can't be used together")
+	}
+	for _, stg := range p.Spec.CopyTo {
+		if stg == p.Spec.StorageName {
+			return fmt.Errorf("spec copyTo can't contain the backup storage %s", stg)
+		}
+	}
+	if len(p.Spec.Namespaces) > 0 && p.Spec.Type != defs.LogicalBackup {
+		return fmt.Errorf("spec namespaces can be used only with logical backup type")
+	}
+	for _, ns := range p.Spec.Namespaces {
+		if err := validateNamespace(ns); err != nil {
+			return fmt.Errorf("spec namespaces: %v", err)
+		}
+	}
+	if err := p.Spec.Hooks.Validate(); err != nil {
+		return fmt.Errorf("spec hooks: %v", err)
+	}
 	if string(p.Spec.Compression) == "" {
 		p.Spec.Compression = compress.CompressionTypeGZIP
 	}
 	return nil
 }
 
+func validateNamespace(ns string) error {
+	db, coll, ok := strings.Cut(ns, ".")
+	if !ok || db == "" || coll == "" {
+		return fmt.Errorf("invalid namespace %q, should be in the db.* or db.collection format", ns)
+	}
+	if db == "*" && coll != "*" {
+		return fmt.Errorf("invalid namespace %q, collection can't be specified for all databases", ns)
+	}
+	return nil
+}
+
+// IsSelective returns true if the backup contains only some of the namespaces.
+// Selective backups can't be used as a base for point-in-time recovery.
+func (s *PerconaServerMongoDBBackupStatus) IsSelective() bool {
+	return len(s.Namespaces) > 0
+}
+
+// ContainsNamespace returns true if the namespace of a selective restore overlaps
+// with the namespaces of the backup. All namespaces are contained in a full backup.
+func (s *PerconaServerMongoDBBackupStatus) ContainsNamespace(ns string) bool {
+	if len(s.Namespaces) == 0 {
+		return true
+	}
+
+	db, coll, _ := strings.Cut(ns, ".")
+	for _, bns := range s.Namespaces {
+		bdb, bcoll, _ := strings.Cut(bns, ".")
+		if bdb == "*" {
+			return true
+		}
+		if bdb == db && (bcoll == "*" || coll == "*" || bcoll == coll) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// VerificationPending returns true if the backup is ready
+// and it's requested to be verified but verification isn't finished yet.
+func (p *PerconaServerMongoDBBackup) VerificationPending() bool {
+	return p.Spec.Verify && p.Status.State == BackupStateReady && !p.Status.Verification.Finished()
+}
+
+// PostHooksPending returns true if the backup is finished and post hooks are not run yet.
+func (p *PerconaServerMongoDBBackup) PostHooksPending() bool {
+	if p.Status.State != BackupStateReady && p.Status.State != BackupStateError {
+		return false
+	}
+	if p.Spec.Hooks == nil || len(p.Spec.Hooks.Post) == 0 {
+		return false
+	}
+
+	for _, h := range p.Status.Hooks {
+		if h.Phase == BackupHookPhasePost {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CopyStatus returns the status of the backup copy in the given storage.
+func (p *PerconaServerMongoDBBackup) CopyStatus(storageName string) *BackupCopyStatus {
+	for i := range p.Status.Copies {
+		if p.Status.Copies[i].StorageName == storageName {
+			return &p.Status.Copies[i]
+		}
+	}
+	return nil
+}
+
+// CopyPending returns true if the backup is ready and some of the copies are not finished yet.
+func (p *PerconaServerMongoDBBackup) CopyPending() bool {
+	if p.Status.State != BackupStateReady {
+		return false
+	}
+
+	for _, stg := range p.Spec.CopyTo {
+		c := p.CopyStatus(stg)
+		if c == nil || (c.State != BackupStateReady && c.State != BackupStateError) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expired returns true if the backup is finished and its expiration time
+// set by expiresAt or ttl has passed.
+func (p *PerconaServerMongoDBBackup) Expired(now time.Time) bool {
+	if p.Status.State != BackupStateReady && p.Status.State != BackupStateError {
+		return false
+	}
+
+	switch {
+	case p.Spec.ExpiresAt != nil:
+		return !now.Before(p.Spec.ExpiresAt.Time)
+	case p.Spec.TTL != nil:
+		return !now.Before(p.CreationTimestamp.Add(p.Spec.TTL.Duration))
+	}
+
+	return false
+}
+
 // GetClusterName returns ClusterName if it's not empty. Otherwise, it will return PSMDBCluster.
 // TODO: Remove after v1.15
 func (p *PerconaServerMongoDBBackupSpec) GetClusterName() string {
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBackupExpired(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	created := metav1.NewTime(now.Add(-48 * time.Hour))
+
+	tests := map[string]struct {
+		state     BackupState
+		expiresAt *metav1.Time
+		ttl       *metav1.Duration
+		expected  bool
+	}{
+		"no expiration": {
+			state: BackupStateReady,
+		},
+		"expiresAt passed": {
+			state:     BackupStateReady,
+			expiresAt: &metav1.Time{Time: now.Add(-time.Minute)},
+			expected:  true,
+		},
+		"expiresAt in future": {
+			state:     BackupStateReady,
+			expiresAt: &metav1.Time{Time: now.Add(time.Minute)},
+		},
+		"ttl passed": {
+			state:    BackupStateError,
+			ttl:      &metav1.Duration{Duration: 24 * time.Hour},
+			expected: true,
+		},
+		"ttl not passed": {
+			state: BackupStateReady,
+			ttl:   &metav1.Duration{Duration: 72 * time.Hour},
+		},
+		"running backup": {
+			state: BackupStateRunning,
+			ttl:   &metav1.Duration{Duration: time.Hour},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			bcp := &PerconaServerMongoDBBackup{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created},
+				Spec: PerconaServerMongoDBBackupSpec{
+					ExpiresAt: tt.expiresAt,
+					TTL:       tt.ttl,
+				},
+				Status: PerconaServerMongoDBBackupStatus{State: tt.state},
+			}
+
+			if got := bcp.Expired(now); got != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBackupContainsNamespace(t *testing.T) {
+	tests := map[string]struct {
+		backup   []string
+		ns       string
+		expected bool
+	}{
+		"full backup":                    {ns: "app.users", expected: true},
+		"all databases":                  {backup: []string{"*.*"}, ns: "app.users", expected: true},
+		"same collection":                {backup: []string{"app.users"}, ns: "app.users", expected: true},
+		"collection of backed up db":     {backup: []string{"app.*"}, ns: "app.users", expected: true},
+		"db of backed up collection":     {backup: []string{"app.users"}, ns: "app.*", expected: true},
+		"another collection":             {backup: []string{"app.users"}, ns: "app.orders"},
+		"another db":                     {backup: []string{"app.*", "billing.invoices"}, ns: "archive.*"},
+		"collection of second namespace": {backup: []string{"app.*", "billing.invoices"}, ns: "billing.invoices", expected: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			status := PerconaServerMongoDBBackupStatus{Namespaces: tt.backup}
+			if got := status.ContainsNamespace(tt.ns); got != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
@@ -3,10 +3,12 @@ package v1
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/percona/percona-backup-mongodb/pbm/defs"
@@ -21,6 +23,16 @@ type PerconaServerMongoDBRestoreSpec struct {
 	StorageName  string                            `json:"storageName,omitempty"`
 	PITR         *PITRestoreSpec                   `json:"pitr,omitempty"`
 	Selective    *SelectiveRestoreOpts             `json:"selective,omitempty"`
+	// ReplsetRemapping maps replset names in the backup to replset names in the target cluster.
+	// Replsets that are not in the mapping are restored to replsets with the same name.
+	ReplsetRemapping map[string]string `json:"replsetRemapping,omitempty"`
+	// DryRun checks that the backup can be restored without changing the cluster.
+	// The results of the checks are reported in status.dryRun.
+	DryRun bool `json:"dryRun,omitempty"`
+	// Retries is the number of times a failed physical restore is run again
+	// after the statefulsets of the cluster are rolled back.
+	// +kubebuilder:validation:Minimum=0
+	Retries int32 `json:"retries,omitempty"`
 }
 
 type SelectiveRestoreOpts struct {
@@ -63,8 +75,165 @@ type PerconaServerMongoDBRestoreStatus struct {
 	Error          string       `json:"error,omitempty"`
 	CompletedAt    *metav1.Time `json:"completed,omitempty"`
 	LastTransition *metav1.Time `json:"lastTransition,omitempty"`
+	// DryRun is the report of the pre-flight checks of a dry run restore.
+	DryRun *RestoreDryRunStatus `json:"dryRun,omitempty"`
+	// Progress is the progress of a physical restore for each replset and node.
+	Progress *RestoreProgress `json:"progress,omitempty"`
+	// Rollback is the rollback of the statefulsets after a failed physical restore.
+	Rollback *RestoreRollbackStatus `json:"rollback,omitempty"`
 }
 
+// RestoreRollbackState is the state of the rollback of the statefulsets after a failed physical restore.
+type RestoreRollbackState string
+
+const (
+	RestoreRollbackRunning    RestoreRollbackState = "running"
+	RestoreRollbackRolledBack RestoreRollbackState = "rolledBack"
+)
+
+// RestoreRollbackStatus reports the rollback of the statefulsets after a failed physical restore.
+// The statefulsets are rolled back to the specs they had before the restore. The data of the cluster
+// isn't rolled back, it may be partially restored if PBM started to copy the backup files.
+type RestoreRollbackStatus struct {
+	State RestoreRollbackState `json:"state,omitempty"`
+	// Reason is the error of the failed restore attempt.
+	Reason string `json:"reason,omitempty"`
+	// Error is the last error of the rollback, the rollback is retried until it succeeds.
+	Error string `json:"error,omitempty"`
+	// Attempts is the number of failed restore attempts.
+	Attempts    int32        `json:"attempts,omitempty"`
+	CompletedAt *metav1.Time `json:"completed,omitempty"`
+}
+
+// RestorePhase is a phase of a physical restore.
+// In the restoringData phase PBM downloads the backup files and replays oplog.
+// PBM reports a node of a physical restore only as starting, running or done
+// and doesn't record download statistics, so the progress of the download
+// in bytes or percent and a separate oplog replay phase are not supported.
+type RestorePhase string
+
+const (
+	RestorePhasePreparingStatefulSets RestorePhase = "preparingStatefulSets"
+	RestorePhaseRestoringData         RestorePhase = "restoringData"
+	RestorePhaseRestartingNodes       RestorePhase = "restartingNodes"
+	RestorePhaseResyncingCluster      RestorePhase = "resyncingCluster"
+	RestorePhaseDone                  RestorePhase = "done"
+	RestorePhaseFailed                RestorePhase = "failed"
+)
+
+// RestoreProgress is the progress of a physical restore built from the PBM restore metadata.
+// It contains phases and statuses only, see RestorePhase for what PBM doesn't report.
+type RestoreProgress struct {
+	Phase RestorePhase `json:"phase,omitempty"`
+	// Status is the status of the restore reported by PBM.
+	Status         string                   `json:"status,omitempty"`
+	LastTransition *metav1.Time             `json:"lastTransition,omitempty"`
+	Replsets       []RestoreReplsetProgress `json:"replsets,omitempty"`
+}
+
+type RestoreReplsetProgress struct {
+	Name  string       `json:"name"`
+	Phase RestorePhase `json:"phase,omitempty"`
+	// Status is the status of the replset restore reported by PBM.
+	Status         string                `json:"status,omitempty"`
+	Error          string                `json:"error,omitempty"`
+	LastTransition *metav1.Time          `json:"lastTransition,omitempty"`
+	Nodes          []RestoreNodeProgress `json:"nodes,omitempty"`
+}
+
+type RestoreNodeProgress struct {
+	Name  string       `json:"name"`
+	Phase RestorePhase `json:"phase,omitempty"`
+	// Status is the status of the node restore reported by PBM.
+	Status         string       `json:"status,omitempty"`
+	Error          string       `json:"error,omitempty"`
+	LastTransition *metav1.Time `json:"lastTransition,omitempty"`
+}
+
+// RollbackPending returns true if the physical restore failed
+// and the statefulsets of the cluster aren't rolled back yet.
+// The restore is rolled back only if it failed before the PBM restore was started
+// or PBM reported the error. After an error of the operator the PBM restore
+// may be still running, so the statefulsets are left for the user to check.
+func (r *PerconaServerMongoDBRestore) RollbackPending() bool {
+	if r.Status.State != RestoreStateError || r.Status.Progress == nil {
+		return false
+	}
+	if r.Status.PBMname != "" && r.Status.Progress.Status != string(defs.StatusError) {
+		return false
+	}
+	rb := r.Status.Rollback
+	return rb == nil || rb.State == "" || rb.State == RestoreRollbackRunning
+}
+
+func (p *RestoreProgress) Replset(name string) *RestoreReplsetProgress {
+	if p == nil {
+		return nil
+	}
+	for i := range p.Replsets {
+		if p.Replsets[i].Name == name {
+			return &p.Replsets[i]
+		}
+	}
+	return nil
+}
+
+func (p *RestoreReplsetProgress) Node(name string) *RestoreNodeProgress {
+	if p == nil {
+		return nil
+	}
+	for i := range p.Nodes {
+		if p.Nodes[i].Name == name {
+			return &p.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// Finishing returns true if the data is restored
+// but the nodes are not restarted or the cluster is not resynced yet.
+func (p *RestoreProgress) Finishing() bool {
+	if p == nil {
+		return false
+	}
+	return p.Phase == RestorePhaseRestartingNodes || p.Phase == RestorePhaseResyncingCluster
+}
+
+type RestoreDryRunStatus struct {
+	Passed    bool           `json:"passed"`
+	Checks    []RestoreCheck `json:"checks,omitempty"`
+	CheckedAt *metav1.Time   `json:"checkedAt,omitempty"`
+}
+
+// RestoreCheck is the result of a single pre-flight check of the restore.
+type RestoreCheck struct {
+	Name    RestoreCheckName   `json:"name"`
+	Result  RestoreCheckResult `json:"result"`
+	Message string             `json:"message,omitempty"`
+}
+
+type RestoreCheckName string
+
+const (
+	RestoreCheckBackup   RestoreCheckName = "backup"
+	RestoreCheckStorage  RestoreCheckName = "storage"
+	RestoreCheckVersion  RestoreCheckName = "version"
+	RestoreCheckTopology RestoreCheckName = "topology"
+	RestoreCheckPITR     RestoreCheckName = "pitr"
+
+	// RestoreCheckVolumeSize estimates if the restored data fits the requested size of replset volumes.
+	// Free space on the volumes is not measured.
+	RestoreCheckVolumeSize RestoreCheckName = "volumeSize"
+)
+
+type RestoreCheckResult string
+
+const (
+	RestoreCheckPassed  RestoreCheckResult = "passed"
+	RestoreCheckFailed  RestoreCheckResult = "failed"
+	RestoreCheckSkipped RestoreCheckResult = "skipped"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // PerconaServerMongoDBRestore is the Schema for the perconaservermongodbrestores API
@@ -124,6 +293,17 @@ func (r *PerconaServerMongoDBRestore) CheckFields() error {
 		}
 	}
 
+	targets := make(map[string]struct{}, len(r.Spec.ReplsetRemapping))
+	for from, to := range r.Spec.ReplsetRemapping {
+		if from == "" || to == "" {
+			return errors.New("replsetRemapping can't have empty replset names")
+		}
+		if _, ok := targets[to]; ok {
+			return errors.Errorf("replset %s is used more than once as a target in replsetRemapping", to)
+		}
+		targets[to] = struct{}{}
+	}
+
 	if r.Spec.PITR != nil {
 		switch r.Spec.PITR.Type {
 		case PITRestoreTypeDate:
@@ -131,6 +311,11 @@ func (r *PerconaServerMongoDBRestore) CheckFields() error {
 				return errors.New("date is required for pitr restore by date")
 			}
 
+		case PITRestoreTypeTimestamp:
+			if r.Spec.PITR.Timestamp == nil || r.Spec.PITR.Timestamp.T == 0 {
+				return errors.New("timestamp is required for pitr restore by timestamp")
+			}
+
 		case PITRestoreTypeLatest:
 		// no additional fields required - no validation
 
@@ -163,32 +348,90 @@ func (t *PITRestoreDate) UnmarshalJSON(b []byte) (err error) {
 		return err
 	}
 
-	pt, err := time.Parse("2006-01-02 15:04:05", str)
+	// dates without a time zone are in the local time zone of the operator
+	pt, err := time.ParseInLocation(PITRestoreDateFormat, str, time.Local)
 	if err != nil {
-		return
+		pt, err = time.Parse(time.RFC3339, str)
+		if err != nil {
+			return errors.Errorf("invalid date %q, expected %q or RFC 3339 format", str, PITRestoreDateFormat)
+		}
 	}
 
-	t.Time = metav1.NewTime(pt.Local())
+	t.Time = metav1.NewTime(pt.UTC())
 
 	return nil
 }
 
 func (t *PITRestoreDate) MarshalJSON() ([]byte, error) {
-	return json.Marshal(t.Time.Format("2006-01-02 15:04:05"))
+	return json.Marshal(t.Time.Local().Format(PITRestoreDateFormat))
 }
 
+// PITRestoreDateFormat is the format of the point-in-time restore date without a time zone,
+// such dates are in the local time zone of the operator.
+const PITRestoreDateFormat = "2006-01-02 15:04:05"
+
 type PITRestoreSpec struct {
-	Type PITRestoreType  `json:"type,omitempty"`
-	Date *PITRestoreDate `json:"date,omitempty"`
+	Type PITRestoreType `json:"type,omitempty"`
+	// Date is the time to restore to, in the "YYYY-MM-DD HH:MM:SS" format in the time zone
+	// of the operator (UTC unless TZ is set for it), or in RFC 3339 format with an explicit
+	// time zone, e.g. "2024-05-10T12:30:15Z".
+	Date      *PITRestoreDate      `json:"date,omitempty"`
+	Timestamp *PITRestoreTimestamp `json:"timestamp,omitempty"`
+}
+
+// PITRestoreTimestamp is the BSON timestamp of the oplog operation to restore to.
+// Operations up to and including this timestamp are applied.
+type PITRestoreTimestamp struct {
+	// T is the time in seconds since the Unix epoch.
+	T uint32 `json:"t"`
+	// I is the ordinal of the operation within the second.
+	I uint32 `json:"i,omitempty"`
+}
+
+func (t *PITRestoreTimestamp) BSON() primitive.Timestamp {
+	return primitive.Timestamp{T: t.T, I: t.I}
 }
 
 type PITRestoreType string
 
 var (
-	PITRestoreTypeDate   PITRestoreType = "date"
-	PITRestoreTypeLatest PITRestoreType = "latest"
+	PITRestoreTypeDate      PITRestoreType = "date"
+	PITRestoreTypeLatest    PITRestoreType = "latest"
+	PITRestoreTypeTimestamp PITRestoreType = "timestamp"
 )
 
+// PITRTarget formats the point-in-time restore target the way pbm restore --time accepts it:
+// a date in UTC if the timestamp has no ordinal, otherwise the exact timestamp in the "T,I" format.
+func PITRTarget(ts primitive.Timestamp) string {
+	if ts.I == 0 {
+		return time.Unix(int64(ts.T), 0).UTC().Format("2006-01-02T15:04:05")
+	}
+
+	return fmt.Sprintf("%d,%d", ts.T, ts.I)
+}
+
+// ParsePITRTarget parses the point-in-time restore target formatted by PITRTarget.
+func ParsePITRTarget(s string) (primitive.Timestamp, error) {
+	if t, i, ok := strings.Cut(s, ","); ok {
+		tv, err := strconv.ParseUint(t, 10, 32)
+		if err != nil {
+			return primitive.Timestamp{}, errors.Wrapf(err, "parse seconds of %s", s)
+		}
+		iv, err := strconv.ParseUint(i, 10, 32)
+		if err != nil {
+			return primitive.Timestamp{}, errors.Wrapf(err, "parse ordinal of %s", s)
+		}
+		return primitive.Timestamp{T: uint32(tv), I: uint32(iv)}, nil
+	}
+
+	d, err := time.Parse("2006-01-02T15:04:05", s)
+	if err != nil {
+		return primitive.Timestamp{}, errors.Wrapf(err, "parse date %s", s)
+	}
+
+	return primitive.Timestamp{T: uint32(d.Unix())}, nil
+}
+
 const (
 	AnnotationRestoreInProgress = "percona.com/restore-in-progress"
 	// AnnotationUpdateMongosFirst is an annotation used to force next smart update to be applied to mongos before mongod.
@@ -0,0 +1,196 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+)
+
+func TestPITRestoreDateUnmarshal(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+2", 2*60*60)
+	defer func() { time.Local = local }()
+
+	tests := map[string]struct {
+		input    string
+		expected time.Time
+		wantErr  bool
+	}{
+		"date without time zone is in local time zone": {
+			input:    `"2024-05-10 14:30:15"`,
+			expected: time.Date(2024, 5, 10, 12, 30, 15, 0, time.UTC),
+		},
+		"RFC 3339 with offset": {
+			input:    `"2024-05-10T16:30:15+04:00"`,
+			expected: time.Date(2024, 5, 10, 12, 30, 15, 0, time.UTC),
+		},
+		"RFC 3339 in UTC": {
+			input:    `"2024-05-10T12:30:15Z"`,
+			expected: time.Date(2024, 5, 10, 12, 30, 15, 0, time.UTC),
+		},
+		"invalid date": {
+			input:   `"10.05.2024"`,
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := new(PITRestoreDate)
+			err := json.Unmarshal([]byte(tt.input), d)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !d.Time.Time.Equal(tt.expected) || d.Location() != time.UTC {
+				t.Errorf("expected %v, got %v", tt.expected, d.Time.Time)
+			}
+
+			b, err := json.Marshal(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := `"2024-05-10 14:30:15"`; string(b) != expected {
+				t.Errorf("expected %s, got %s", expected, b)
+			}
+		})
+	}
+}
+
+func TestPITRTarget(t *testing.T) {
+	tests := map[string]struct {
+		ts       primitive.Timestamp
+		expected string
+	}{
+		"date": {
+			ts:       primitive.Timestamp{T: 1715344215},
+			expected: "2024-05-10T12:30:15",
+		},
+		"timestamp with ordinal": {
+			ts:       primitive.Timestamp{T: 1715344215, I: 3},
+			expected: "1715344215,3",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := PITRTarget(tt.ts); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+
+			ts, err := ParsePITRTarget(tt.expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ts != tt.ts {
+				t.Errorf("expected %v, got %v", tt.ts, ts)
+			}
+		})
+	}
+}
+
+func TestRestoreCheckFieldsPITR(t *testing.T) {
+	tests := map[string]struct {
+		pitr    *PITRestoreSpec
+		wantErr bool
+	}{
+		"timestamp": {
+			pitr: &PITRestoreSpec{Type: PITRestoreTypeTimestamp, Timestamp: &PITRestoreTimestamp{T: 1715344215, I: 3}},
+		},
+		"timestamp without value": {
+			pitr:    &PITRestoreSpec{Type: PITRestoreTypeTimestamp},
+			wantErr: true,
+		},
+		"timestamp without seconds": {
+			pitr:    &PITRestoreSpec{Type: PITRestoreTypeTimestamp, Timestamp: &PITRestoreTimestamp{I: 3}},
+			wantErr: true,
+		},
+		"date without value": {
+			pitr:    &PITRestoreSpec{Type: PITRestoreTypeDate},
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := &PerconaServerMongoDBRestore{
+				Spec: PerconaServerMongoDBRestoreSpec{
+					ClusterName: "cluster",
+					BackupName:  "backup",
+					PITR:        tt.pitr,
+				},
+			}
+			err := r.CheckFields()
+			if tt.wantErr && err == nil {
+				t.Error("expected error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRestoreRollbackPending(t *testing.T) {
+	tests := map[string]struct {
+		status   PerconaServerMongoDBRestoreStatus
+		expected bool
+	}{
+		"running": {
+			status: PerconaServerMongoDBRestoreStatus{
+				State:    RestoreStateRunning,
+				Progress: &RestoreProgress{Phase: RestorePhaseRestoringData},
+			},
+		},
+		"logical restore": {
+			status: PerconaServerMongoDBRestoreStatus{State: RestoreStateError},
+		},
+		"failed before PBM restore": {
+			status: PerconaServerMongoDBRestoreStatus{
+				State:    RestoreStateError,
+				Progress: &RestoreProgress{Phase: RestorePhaseFailed},
+			},
+			expected: true,
+		},
+		"failed in PBM": {
+			status: PerconaServerMongoDBRestoreStatus{
+				State:    RestoreStateError,
+				PBMname:  "2024-10-01T10:00:00.000000000Z",
+				Progress: &RestoreProgress{Phase: RestorePhaseFailed, Status: string(defs.StatusError)},
+			},
+			expected: true,
+		},
+		"operator error while PBM restore is running": {
+			status: PerconaServerMongoDBRestoreStatus{
+				State:    RestoreStateError,
+				PBMname:  "2024-10-01T10:00:00.000000000Z",
+				Progress: &RestoreProgress{Phase: RestorePhaseFailed, Status: string(defs.StatusRunning)},
+			},
+		},
+		"rolled back": {
+			status: PerconaServerMongoDBRestoreStatus{
+				State:    RestoreStateError,
+				Progress: &RestoreProgress{Phase: RestorePhaseFailed},
+				Rollback: &RestoreRollbackStatus{State: RestoreRollbackRolledBack},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := &PerconaServerMongoDBRestore{Status: tt.status}
+			if got := r.RollbackPending(); got != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"fmt"
+	"path"
 	"strconv"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/percona/percona-backup-mongodb/pbm/compress"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
 
 	"github.com/percona/percona-server-mongodb-operator/pkg/mcs"
 	"github.com/percona/percona-server-mongodb-operator/pkg/util"
@@ -518,6 +520,12 @@ func (cr *PerconaServerMongoDB) CheckNSetDefaults(platform version.Platform, log
 			if string(bkpTask.CompressionType) == "" {
 				bkpTask.CompressionType = compress.CompressionTypeGZIP
 			}
+			if err := bkpTask.Retention.Validate(); err != nil {
+				return errors.Wrapf(err, "invalid retention for backup task %s", bkpTask.Name)
+			}
+			if bkpTask.IncrementalBase && bkpTask.Type != defs.IncrementalBackup {
+				return errors.Errorf("backup task %s: incrementalBase can be used only with incremental backup type", bkpTask.Name)
+			}
 		}
 		if len(cr.Spec.Backup.ServiceAccountName) == 0 && cr.CompareVersion("1.15.0") < 0 {
 			cr.Spec.Backup.ServiceAccountName = "percona-server-mongodb-operator"
@@ -550,7 +558,28 @@ func (cr *PerconaServerMongoDB) CheckNSetDefaults(platform version.Platform, log
 			}
 		}
 
-		for _, stg := range cr.Spec.Backup.Storages {
+		mainStorages := 0
+		for name, stg := range cr.Spec.Backup.Storages {
+			if err := stg.Retention.Validate(); err != nil {
+				return errors.Wrapf(err, "invalid retention for storage %s", name)
+			}
+
+			if stg.Main {
+				mainStorages++
+			}
+			if mainStorages > 1 {
+				return errors.New("only one storage can be marked as main in spec.backup.storages")
+			}
+
+			if stg.Type == BackupStorageFilesystem && stg.Filesystem.PersistentVolumeClaim != nil {
+				if !path.IsAbs(stg.Filesystem.Path) {
+					return errors.Errorf("filesystem storage %s: path should be absolute to mount the volume", name)
+				}
+				if len(stg.Filesystem.PersistentVolumeClaim.AccessModes) == 0 {
+					stg.Filesystem.PersistentVolumeClaim.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+				}
+			}
+
 			if stg.Type != BackupStorageS3 {
 				continue
 			}
@@ -562,19 +591,43 @@ func (cr *PerconaServerMongoDB) CheckNSetDefaults(platform version.Platform, log
 		}
 	}
 
+	if opts := cr.Spec.Backup.Configuration.BackupOptions; opts != nil && len(opts.PriorityRules) > 0 {
+		if len(opts.Priority) > 0 {
+			return errors.New("priority and priorityRules can't be used together in spec.backup.configuration.backupOptions")
+		}
+		for i, rule := range opts.PriorityRules {
+			if _, err := metav1.LabelSelectorAsSelector(rule.Selector); err != nil {
+				return errors.Wrapf(err, "invalid selector in backup priority rule %d", i)
+			}
+		}
+	}
+
+	if ds := cr.Spec.DataSource; ds != nil {
+		if ds.BackupName == "" && ds.BackupSource == nil {
+			return errors.New("one of backupName or backupSource is required in spec.dataSource")
+		}
+		if !cr.Spec.Backup.Enabled {
+			return errors.New("spec.dataSource requires spec.backup.enabled")
+		}
+	}
+
 	if !cr.Spec.Backup.Enabled {
 		cr.Spec.Backup.PITR.Enabled = false
 	}
 
 	if cr.Spec.Backup.PITR.Enabled {
-		if len(cr.Spec.Backup.Storages) != 1 {
+		if _, _, ok := cr.Spec.Backup.MainStorage(); !ok {
 			cr.Spec.Backup.PITR.Enabled = false
-			log.Info("Point-in-time recovery can be enabled only if one bucket is used in spec.backup.storages")
+			log.Info("Point-in-time recovery can be enabled only if one bucket is used in spec.backup.storages or one of them is marked as main")
 		}
 
 		if cr.Spec.Backup.PITR.OplogSpanMin.Float64() == 0 {
 			cr.Spec.Backup.PITR.OplogSpanMin = numstr.MustParse("10")
 		}
+
+		if r := cr.Spec.Backup.PITR.Retention; r != nil && r.Duration < 0 {
+			return errors.New("spec.backup.pitr.retention should not be negative")
+		}
 	}
 
 	if cr.Status.Replsets == nil {
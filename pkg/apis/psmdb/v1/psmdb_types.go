@@ -3,6 +3,7 @@ package v1
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -95,6 +96,21 @@ type PerconaServerMongoDBSpec struct {
 	Users                        []User                               `json:"users,omitempty"`
 	Roles                        []Role                               `json:"roles,omitempty"`
 	VolumeExpansionEnabled       bool                                 `json:"enableVolumeExpansion,omitempty"`
+	DataSource                   *DataSourceSpec                      `json:"dataSource,omitempty"`
+}
+
+// DataSourceSpec is the backup a new cluster is bootstrapped from.
+// The backup is restored once replsets are initialized,
+// the cluster becomes ready only after the restore is finished.
+// A failed restore is reported in status.dataSource and in the cluster conditions,
+// deleting the restore object of the data source restarts the restore.
+type DataSourceSpec struct {
+	BackupName   string                            `json:"backupName,omitempty"`
+	BackupSource *PerconaServerMongoDBBackupStatus `json:"backupSource,omitempty"`
+	StorageName  string                            `json:"storageName,omitempty"`
+	PITR         *PITRestoreSpec                   `json:"pitr,omitempty"`
+	// ReplsetRemapping maps replset names in the backup to replset names in the cluster.
+	ReplsetRemapping map[string]string `json:"replsetRemapping,omitempty"`
 }
 
 type UserRole struct {
@@ -308,6 +324,8 @@ type PerconaServerMongoDBStatus struct {
 	ObservedGeneration int64                    `json:"observedGeneration,omitempty"`
 	BackupStatus       AppState                 `json:"backup,omitempty"`
 	BackupVersion      string                   `json:"backupVersion,omitempty"`
+	PITR               *PITRStatus              `json:"pitr,omitempty"`
+	DataSource         *DataSourceStatus        `json:"dataSource,omitempty"`
 	PMMStatus          AppState                 `json:"pmmStatus,omitempty"`
 	PMMVersion         string                   `json:"pmmVersion,omitempty"`
 	Host               string                   `json:"host,omitempty"`
@@ -315,6 +333,23 @@ type PerconaServerMongoDBStatus struct {
 	Ready              int32                    `json:"ready"`
 }
 
+// PITRStatus reports the window the cluster can be restored to with point-in-time recovery
+type PITRStatus struct {
+	EarliestRestorableTime *metav1.Time `json:"earliestRestorableTime,omitempty"`
+	LatestRestorableTime   *metav1.Time `json:"latestRestorableTime,omitempty"`
+	// LastRetentionTime is the last time the operator deleted oplog chunks outside of spec.backup.pitr.retention
+	LastRetentionTime *metav1.Time `json:"lastRetentionTime,omitempty"`
+}
+
+// DataSourceStatus reports the restore of spec.dataSource
+type DataSourceStatus struct {
+	// Restore is the name of the restore object created for spec.dataSource
+	Restore     string       `json:"restore,omitempty"`
+	State       RestoreState `json:"state,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	CompletedAt *metav1.Time `json:"completed,omitempty"`
+}
+
 type ConditionStatus string
 
 const (
@@ -865,19 +900,178 @@ type BackupTaskSpec struct {
 	Name             string                   `json:"name"`
 	Enabled          bool                     `json:"enabled"`
 	Keep             int                      `json:"keep,omitempty"`
+	Retention        *BackupRetention         `json:"retention,omitempty"`
 	Schedule         string                   `json:"schedule,omitempty"`
 	StorageName      string                   `json:"storageName,omitempty"`
 	CompressionType  compress.CompressionType `json:"compressionType,omitempty"`
 	CompressionLevel *int                     `json:"compressionLevel,omitempty"`
 
-	// +kubebuilder:validation:Enum={logical,physical}
+	// +kubebuilder:validation:Enum={logical,physical,incremental}
 	Type defs.BackupType `json:"type,omitempty"`
+
+	// IncrementalBase makes the task start a new chain of incremental backups.
+	IncrementalBase bool `json:"incrementalBase,omitempty"`
+
+	// Verify makes the operator verify each backup of the task after it's completed.
+	Verify bool `json:"verify,omitempty"`
+
+	Hooks *BackupHooks `json:"hooks,omitempty"`
+
+	// Namespaces limits backups of the task to the given databases and collections.
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 func (task *BackupTaskSpec) JobName(cr *PerconaServerMongoDB) string {
 	return fmt.Sprintf("%s-backup-%s-%s", cr.Name, task.Name, cr.Namespace)
 }
 
+// BackupRetention defines which scheduled backups are kept.
+// A backup older than MaxAge is deleted. If any of the count based rules
+// (Daily, Weekly, Monthly) is set, a backup that is not selected by at least
+// one of them is deleted as well. The most recent ready backup is never deleted.
+// Failed backups are deleted once they are older than MaxAge, and failed or stuck
+// backups are deleted once they are older than the oldest retained ready backup.
+type BackupRetention struct {
+	MaxAge  *metav1.Duration `json:"maxAge,omitempty"`
+	Daily   int              `json:"daily,omitempty"`
+	Weekly  int              `json:"weekly,omitempty"`
+	Monthly int              `json:"monthly,omitempty"`
+}
+
+func (r *BackupRetention) HasTiers() bool {
+	if r == nil {
+		return false
+	}
+	return r.Daily > 0 || r.Weekly > 0 || r.Monthly > 0
+}
+
+func (r *BackupRetention) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.Daily < 0 || r.Weekly < 0 || r.Monthly < 0 {
+		return errors.New("daily, weekly and monthly can't be negative")
+	}
+	if r.MaxAge != nil && r.MaxAge.Duration < 0 {
+		return errors.New("maxAge can't be negative")
+	}
+	return nil
+}
+
+func (r *BackupRetention) IsEmpty() bool {
+	if r == nil {
+		return true
+	}
+	return !r.HasTiers() && (r.MaxAge == nil || r.MaxAge.Duration == 0)
+}
+
+type BackupHookPhase string
+
+const (
+	BackupHookPhasePre  BackupHookPhase = "pre"
+	BackupHookPhasePost BackupHookPhase = "post"
+)
+
+type BackupHookFailurePolicy string
+
+const (
+	BackupHookFailurePolicyAbort    BackupHookFailurePolicy = "abort"
+	BackupHookFailurePolicyContinue BackupHookFailurePolicy = "continue"
+)
+
+// BackupHooks are run around a backup. Pre hooks are run before the backup is started,
+// post hooks are run after the backup is finished either way.
+type BackupHooks struct {
+	Pre  []BackupHook `json:"pre,omitempty"`
+	Post []BackupHook `json:"post,omitempty"`
+}
+
+type BackupHook struct {
+	Name string          `json:"name"`
+	Exec *BackupHookExec `json:"exec,omitempty"`
+	HTTP *BackupHookHTTP `json:"http,omitempty"`
+
+	// Timeout for the hook, 1m by default.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// FailurePolicy defines what happens if the hook fails. A failed pre hook with the abort
+	// policy fails the backup. A failed post hook with the abort policy skips the remaining post hooks.
+	// +kubebuilder:validation:Enum={abort,continue}
+	FailurePolicy BackupHookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// BackupHookExec runs the command in the container of the pod
+// with the given name or in the first running pod that matches the selector.
+// Only pods of the backup's cluster can be used.
+type BackupHookExec struct {
+	Pod       string                `json:"pod,omitempty"`
+	Selector  *metav1.LabelSelector `json:"selector,omitempty"`
+	Container string                `json:"container"`
+	Command   []string              `json:"command"`
+}
+
+// BackupHookHTTP sends a request to the http or https URL. If body is empty,
+// the request of a post hook contains the backup status in JSON.
+// Redirects are not followed and loopback and link-local destinations are not allowed.
+type BackupHookHTTP struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+func (h *BackupHooks) Validate() error {
+	if h == nil {
+		return nil
+	}
+
+	for _, hook := range append(append([]BackupHook{}, h.Pre...), h.Post...) {
+		if hook.Name == "" {
+			return errors.New("hook name can't be empty")
+		}
+		if (hook.Exec == nil) == (hook.HTTP == nil) {
+			return errors.Errorf("hook %s: exactly one of exec and http should be specified", hook.Name)
+		}
+		if hook.Exec != nil {
+			if hook.Exec.Container == "" || len(hook.Exec.Command) == 0 {
+				return errors.Errorf("hook %s: exec container and command can't be empty", hook.Name)
+			}
+			if (hook.Exec.Pod == "") == (hook.Exec.Selector == nil) {
+				return errors.Errorf("hook %s: exactly one of exec pod and selector should be specified", hook.Name)
+			}
+		}
+		if hook.HTTP != nil {
+			if hook.HTTP.URL == "" {
+				return errors.Errorf("hook %s: http url can't be empty", hook.Name)
+			}
+			u, err := url.Parse(hook.HTTP.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return errors.Errorf("hook %s: http url should be an absolute http or https url", hook.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetTaskRetention returns the retention policy of the task. If the task
+// doesn't define one, the retention policy of the task's storage is used.
+// Tasks without a storage name use the main storage.
+func (b BackupSpec) GetTaskRetention(task BackupTaskSpec) *BackupRetention {
+	if !task.Retention.IsEmpty() {
+		return task.Retention
+	}
+
+	stg, ok := b.Storages[task.StorageName]
+	if task.StorageName == "" {
+		_, stg, ok = b.MainStorage()
+	}
+	if !ok || stg.Retention.IsEmpty() {
+		return nil
+	}
+	return stg.Retention
+}
+
 type S3ServiceSideEncryption struct {
 	// Used to specify the SSE algorithm used when keys are managed by the server
 	SSEAlgorithm string `json:"sseAlgorithm,omitempty"`
@@ -923,6 +1117,11 @@ type BackupStorageAzureSpec struct {
 
 type BackupStorageFilesystemSpec struct {
 	Path string `json:"path"`
+	// PersistentVolumeClaim is the spec of the shared volume the operator creates
+	// for the storage and mounts at the path into backup-agent containers
+	// and physical restore pods. The volume should support ReadWriteMany access mode,
+	// which is used by default.
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaim,omitempty"`
 }
 
 type BackupStorageType string
@@ -934,10 +1133,15 @@ const (
 )
 
 type BackupStorageSpec struct {
+	// Main marks the storage that holds PITR oplog chunks if more than one
+	// storage is configured. Other storages are used as PBM config profiles.
+	Main bool `json:"main,omitempty"`
+
 	Type       BackupStorageType           `json:"type"`
 	S3         BackupStorageS3Spec         `json:"s3,omitempty"`
 	Azure      BackupStorageAzureSpec      `json:"azure,omitempty"`
 	Filesystem BackupStorageFilesystemSpec `json:"filesystem,omitempty"`
+	Retention  *BackupRetention            `json:"retention,omitempty"`
 }
 
 type PITRSpec struct {
@@ -946,6 +1150,9 @@ type PITRSpec struct {
 	OplogOnly        bool                     `json:"oplogOnly,omitempty"`
 	CompressionType  compress.CompressionType `json:"compressionType,omitempty"`
 	CompressionLevel *int                     `json:"compressionLevel,omitempty"`
+	// Retention is the window of oplog chunks to keep, e.g. 72h. Older chunks are deleted
+	// unless they are needed to restore to the latest restorable time from the oldest backup.
+	Retention *metav1.Duration `json:"retention,omitempty"`
 }
 
 func (p PITRSpec) Disabled() PITRSpec {
@@ -955,12 +1162,49 @@ func (p PITRSpec) Disabled() PITRSpec {
 
 type BackupTimeouts struct {
 	Starting *uint32 `json:"startingStatus,omitempty"`
+
+	// Running is the maximum time in seconds a backup can be running.
+	// The backup is canceled after it. If PBM doesn't cancel the backup in 2 minutes,
+	// the backup is failed and its locks are released. There is no limit by default.
+	Running *uint32 `json:"runningStatus,omitempty"`
+
+	// Heartbeat is the time in seconds after the last PBM heartbeat of a running backup
+	// after which the backup is considered stalled, canceled and its locks are released.
+	// Stalled backups are not detected by default.
+	Heartbeat *uint32 `json:"heartbeat,omitempty"`
 }
 
 type BackupOptions struct {
 	OplogSpanMin float64            `json:"oplogSpanMin"`
 	Priority     map[string]float64 `json:"priority,omitempty"`
-	Timeouts     *BackupTimeouts    `json:"timeouts,omitempty"`
+	// PriorityRules set PBM priorities of replset members by pod labels, member roles or tags.
+	// The operator translates them into priorities as the topology changes.
+	// They can't be used together with priority.
+	PriorityRules []BackupPriorityRule `json:"priorityRules,omitempty"`
+	Timeouts      *BackupTimeouts      `json:"timeouts,omitempty"`
+}
+
+// BackupMemberRole is the role of the replset member used to select nodes for backups
+// +kubebuilder:validation:Enum={primary,secondary,hidden,nonVoting}
+type BackupMemberRole string
+
+const (
+	BackupMemberRolePrimary   BackupMemberRole = "primary"
+	BackupMemberRoleSecondary BackupMemberRole = "secondary"
+	BackupMemberRoleHidden    BackupMemberRole = "hidden"
+	BackupMemberRoleNonVoting BackupMemberRole = "nonVoting"
+)
+
+// BackupPriorityRule sets the PBM priority of replset members that match all conditions of the rule.
+// Rules are checked in order and the first matching rule is applied. Members without a matching
+// rule have priority 1.0, except the primary, which has priority 0.5.
+type BackupPriorityRule struct {
+	// Selector matches labels of member pods.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	Role     BackupMemberRole      `json:"role,omitempty"`
+	// Tags match replset member tags, e.g. zone and region of the member node.
+	Tags     map[string]string `json:"tags,omitempty"`
+	Priority float64           `json:"priority"`
 }
 
 type RestoreOptions struct {
@@ -993,18 +1237,46 @@ type BackupSpec struct {
 	PITR                     PITRSpec                     `json:"pitr,omitempty"`
 	Configuration            BackupConfig                 `json:"configuration,omitempty"`
 	VolumeMounts             []corev1.VolumeMount         `json:"volumeMounts,omitempty"`
+
+	// ImportBackups makes the operator create backup objects for completed backups
+	// PBM finds in the storages after a resync requested with the percona.com/resync-pbm annotation.
+	// Imported backups are read-only: deleting them doesn't delete the data from the storage
+	// even with the percona.com/delete-backup finalizer, and they fail if their spec is changed
+	// to describe another backup.
+	ImportBackups bool `json:"importBackups,omitempty"`
 }
 
 func (b BackupSpec) IsEnabledPITR() bool {
 	if !b.Enabled {
 		return false
 	}
-	if len(b.Storages) != 1 {
+	if _, _, ok := b.MainStorage(); !ok {
 		return false
 	}
 	return b.PITR.Enabled
 }
 
+// MainStorage returns the storage that is used as PBM main storage.
+// It is the only configured storage or the one marked as main.
+func (b BackupSpec) MainStorage() (string, BackupStorageSpec, bool) {
+	for name, stg := range b.Storages {
+		if len(b.Storages) == 1 || stg.Main {
+			return name, stg, true
+		}
+	}
+	return "", BackupStorageSpec{}, false
+}
+
+// StorageProfile returns the name of PBM config profile that should be used
+// for the storage. It's empty if the storage should be set as PBM main storage.
+func (b BackupSpec) StorageProfile(storageName string) string {
+	mainName, _, ok := b.MainStorage()
+	if !ok || mainName == storageName {
+		return ""
+	}
+	return storageName
+}
+
 type Arbiter struct {
 	MultiAZ `json:",inline"`
 
@@ -1193,6 +1465,11 @@ func (cr *PerconaServerMongoDB) CanRestore(ctx context.Context) error {
 	return nil
 }
 
+// DataSourcePending returns true if spec.dataSource isn't restored yet.
+func (cr *PerconaServerMongoDB) DataSourcePending() bool {
+	return cr.Spec.DataSource != nil && (cr.Status.DataSource == nil || cr.Status.DataSource.State != RestoreStateReady)
+}
+
 const maxStatusesQuantity = 20
 
 func (s *PerconaServerMongoDBStatus) AddCondition(c ClusterCondition) {
@@ -1248,5 +1525,7 @@ func (cr *PerconaServerMongoDB) UnsafeTLSDisabled() bool {
 
 const (
 	AnnotationResyncPBM           = "percona.com/resync-pbm"
+	AnnotationCancelBackup        = "percona.com/cancel-backup"
+	AnnotationImportBackups       = "percona.com/import-backups"
 	AnnotationPVCResizeInProgress = "percona.com/pvc-resize-in-progress"
 )
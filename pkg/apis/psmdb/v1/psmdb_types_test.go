@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"testing"
+)
+
+func TestGetTaskRetention(t *testing.T) {
+	taskRetention := &BackupRetention{Daily: 1}
+	mainRetention := &BackupRetention{Daily: 7}
+	otherRetention := &BackupRetention{Weekly: 4}
+
+	spec := BackupSpec{
+		Storages: map[string]BackupStorageSpec{
+			"main":  {Main: true, Retention: mainRetention},
+			"other": {Retention: otherRetention},
+			"none":  {},
+		},
+	}
+
+	tests := map[string]struct {
+		task     BackupTaskSpec
+		expected *BackupRetention
+	}{
+		"task retention": {
+			task:     BackupTaskSpec{StorageName: "other", Retention: taskRetention},
+			expected: taskRetention,
+		},
+		"storage retention": {
+			task:     BackupTaskSpec{StorageName: "other"},
+			expected: otherRetention,
+		},
+		"main storage retention": {
+			task:     BackupTaskSpec{},
+			expected: mainRetention,
+		},
+		"storage without retention": {
+			task: BackupTaskSpec{StorageName: "none"},
+		},
+		"unknown storage": {
+			task: BackupTaskSpec{StorageName: "unknown"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := spec.GetTaskRetention(tt.task); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
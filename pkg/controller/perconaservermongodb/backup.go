@@ -5,7 +5,9 @@ import (
 	"container/heap"
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
+	"sort"
 	"strconv"
 	"time"
 
@@ -24,9 +26,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/percona/percona-backup-mongodb/pbm/config"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+
 	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
 	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/metrics"
 )
 
 type BackupScheduleJob struct {
@@ -118,8 +124,9 @@ func (r *ReconcilePerconaServerMongoDB) deleteOldBackupTasks(ctx context.Context
 
 		for _, t := range tasksList.Items {
 			if spec, ok := ctasks[t.Name]; ok {
-				if spec.Keep > 0 {
-					oldjobs, err := r.oldScheduledBackups(ctx, cr, t.Name, spec.Keep)
+				retention := cr.Spec.Backup.GetTaskRetention(spec)
+				if spec.Keep > 0 || retention != nil {
+					oldjobs, err := r.oldScheduledBackups(ctx, cr, t.Name, spec.Keep, retention)
 					if err != nil {
 						return fmt.Errorf("remove old backups: %v", err)
 					}
@@ -143,14 +150,16 @@ func (r *ReconcilePerconaServerMongoDB) deleteOldBackupTasks(ctx context.Context
 	r.crons.backupJobs.Range(func(k, v interface{}) bool {
 		item := v.(BackupScheduleJob)
 		if spec, ok := ctasks[item.Name]; ok {
-			if spec.Keep > 0 {
-				oldjobs, err := r.oldScheduledBackups(ctx, cr, item.Name, spec.Keep)
+			retention := cr.Spec.Backup.GetTaskRetention(spec)
+			if spec.Keep > 0 || retention != nil {
+				oldjobs, err := r.oldScheduledBackups(ctx, cr, item.Name, spec.Keep, retention)
 				if err != nil {
 					log.Error(err, "failed to list old backups", "job", item.Name)
 					return true
 				}
 
 				for _, todel := range oldjobs {
+					log.Info("Deleting backup that is out of retention", "backup", todel.Name, "task", item.Name)
 					err = r.client.Delete(ctx, &todel)
 					if err != nil {
 						log.Error(err, "failed to delete backup object")
@@ -202,8 +211,9 @@ func (r *ReconcilePerconaServerMongoDB) deleteBackupTask(cr *api.PerconaServerMo
 }
 
 // oldScheduledBackups returns list of the most old psmdb-bakups that execeed `keep` limit
+// or are not retained by the given retention policy
 func (r *ReconcilePerconaServerMongoDB) oldScheduledBackups(ctx context.Context, cr *api.PerconaServerMongoDB,
-	ancestor string, keep int,
+	ancestor string, keep int, retention *api.BackupRetention,
 ) ([]api.PerconaServerMongoDBBackup, error) {
 	bcpList := api.PerconaServerMongoDBBackupList{}
 	err := r.client.List(ctx,
@@ -220,6 +230,10 @@ func (r *ReconcilePerconaServerMongoDB) oldScheduledBackups(ctx context.Context,
 		return []api.PerconaServerMongoDBBackup{}, err
 	}
 
+	if retention != nil {
+		return keepIncrementalChains(bcpList.Items, expiredBackups(bcpList.Items, keep, retention, time.Now())), nil
+	}
+
 	if len(bcpList.Items) <= keep {
 		return []api.PerconaServerMongoDBBackup{}, nil
 	}
@@ -232,7 +246,7 @@ func (r *ReconcilePerconaServerMongoDB) oldScheduledBackups(ctx context.Context,
 		}
 	}
 
-	if h.Len() <= keep {
+	if h.Len() < keep {
 		return []api.PerconaServerMongoDBBackup{}, nil
 	}
 
@@ -241,8 +255,185 @@ func (r *ReconcilePerconaServerMongoDB) oldScheduledBackups(ctx context.Context,
 		o := heap.Pop(h).(api.PerconaServerMongoDBBackup)
 		ret = append(ret, o)
 	}
+	if h.Len() > 0 {
+		ret = append(ret, staleBackups(bcpList.Items, (*h)[0].CreationTimestamp.Time, nil, time.Now())...)
+	}
 
-	return ret, nil
+	return keepIncrementalChains(bcpList.Items, ret), nil
+}
+
+// keepIncrementalChains removes backups of incremental chains from the expired ones,
+// unless all backups of the chain are expired. Every increment depends on all previous
+// backups of its chain, and PBM deletes them only as a whole chain with its base.
+// Increments whose base isn't in the list are never expired, they are deleted with the base.
+func keepIncrementalChains(bcps, expired []api.PerconaServerMongoDBBackup) []api.PerconaServerMongoDBBackup {
+	byPBMName := make(map[string]api.PerconaServerMongoDBBackup, len(bcps))
+	for _, bcp := range bcps {
+		if bcp.Status.PBMname != "" {
+			byPBMName[bcp.Status.PBMname] = bcp
+		}
+	}
+
+	// chainBase returns the name of the chain unit the backup belongs to,
+	// it's empty if the base of the chain isn't in the list
+	chainBase := func(bcp api.PerconaServerMongoDBBackup) string {
+		if bcp.Status.Type != defs.IncrementalBackup {
+			return bcp.Name
+		}
+		for i := 0; bcp.Status.SrcBackup != "" && i < len(bcps); i++ {
+			src, ok := byPBMName[bcp.Status.SrcBackup]
+			if !ok {
+				return ""
+			}
+			bcp = src
+		}
+		if bcp.Status.SrcBackup != "" {
+			return ""
+		}
+		return bcp.Name
+	}
+
+	isExpired := make(map[string]struct{}, len(expired))
+	for _, bcp := range expired {
+		isExpired[bcp.Name] = struct{}{}
+	}
+
+	retainedChains := make(map[string]struct{})
+	for _, bcp := range bcps {
+		if bcp.Status.State == api.BackupStateError {
+			continue
+		}
+		if _, ok := isExpired[bcp.Name]; !ok {
+			retainedChains[chainBase(bcp)] = struct{}{}
+		}
+	}
+
+	ret := make([]api.PerconaServerMongoDBBackup, 0, len(expired))
+	for _, bcp := range expired {
+		// backups that aren't ready hold no data other backups depend on
+		if bcp.Status.State != api.BackupStateReady {
+			ret = append(ret, bcp)
+			continue
+		}
+		base := chainBase(bcp)
+		if base == "" {
+			continue
+		}
+		if _, ok := retainedChains[base]; ok {
+			continue
+		}
+		ret = append(ret, bcp)
+	}
+
+	return ret
+}
+
+// expiredBackups returns backups that are not retained by the given policy.
+// The `keep` most recent ready backups and the most recent ready backup of each of the last
+// daily, weekly and monthly periods are retained, unless they are older than maxAge.
+// The most recent ready backup is always retained.
+// Backups that aren't ready are expired as returned by staleBackups.
+func expiredBackups(bcps []api.PerconaServerMongoDBBackup, keep int, retention *api.BackupRetention, now time.Time) []api.PerconaServerMongoDBBackup {
+	ready := make([]api.PerconaServerMongoDBBackup, 0, len(bcps))
+	for _, bcp := range bcps {
+		if bcp.Status.State == api.BackupStateReady {
+			ready = append(ready, bcp)
+		}
+	}
+	if len(ready) == 0 {
+		return staleBackups(bcps, time.Time{}, retention.MaxAge, now)
+	}
+
+	// newest first
+	sort.Slice(ready, func(i, j int) bool {
+		return ready[j].CreationTimestamp.Before(&ready[i].CreationTimestamp)
+	})
+
+	countBased := keep > 0 || retention.HasTiers()
+
+	retained := make(map[string]struct{})
+	for i := 0; i < keep && i < len(ready); i++ {
+		retained[ready[i].Name] = struct{}{}
+	}
+	retainPeriods(ready, retention.Daily, retained, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	retainPeriods(ready, retention.Weekly, retained, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+	retainPeriods(ready, retention.Monthly, retained, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+
+	var expired []api.PerconaServerMongoDBBackup
+	for _, bcp := range ready[1:] {
+		if retention.MaxAge != nil && retention.MaxAge.Duration > 0 && now.Sub(bcp.CreationTimestamp.Time) > retention.MaxAge.Duration {
+			expired = append(expired, bcp)
+			continue
+		}
+
+		if _, ok := retained[bcp.Name]; countBased && !ok {
+			expired = append(expired, bcp)
+		}
+	}
+
+	isExpired := make(map[string]struct{}, len(expired))
+	for _, bcp := range expired {
+		isExpired[bcp.Name] = struct{}{}
+	}
+	oldestRetained := ready[0].CreationTimestamp.Time
+	for _, bcp := range ready {
+		if _, ok := isExpired[bcp.Name]; !ok {
+			oldestRetained = bcp.CreationTimestamp.Time
+		}
+	}
+
+	return append(expired, staleBackups(bcps, oldestRetained, retention.MaxAge, now)...)
+}
+
+// staleBackups returns backups that aren't ready and were created before the oldest
+// retained ready backup, and failed backups older than maxAge. Such backups hold no
+// restorable data, so they are expired to not accumulate.
+func staleBackups(bcps []api.PerconaServerMongoDBBackup, oldestRetained time.Time, maxAge *metav1.Duration, now time.Time) []api.PerconaServerMongoDBBackup {
+	var stale []api.PerconaServerMongoDBBackup
+	for _, bcp := range bcps {
+		if bcp.Status.State == api.BackupStateReady {
+			continue
+		}
+
+		if bcp.CreationTimestamp.Time.Before(oldestRetained) {
+			stale = append(stale, bcp)
+			continue
+		}
+
+		if bcp.Status.State == api.BackupStateError && maxAge != nil && maxAge.Duration > 0 && now.Sub(bcp.CreationTimestamp.Time) > maxAge.Duration {
+			stale = append(stale, bcp)
+		}
+	}
+
+	return stale
+}
+
+// retainPeriods marks the most recent backup of each of the last n periods as retained.
+// Backups should be sorted from newest to oldest.
+func retainPeriods(bcps []api.PerconaServerMongoDBBackup, n int, retained map[string]struct{}, period func(time.Time) string) {
+	if n <= 0 {
+		return
+	}
+
+	periods := make(map[string]struct{})
+	for _, bcp := range bcps {
+		p := period(bcp.CreationTimestamp.UTC())
+		if _, ok := periods[p]; ok {
+			continue
+		}
+		if len(periods) == n {
+			return
+		}
+		periods[p] = struct{}{}
+		retained[bcp.Name] = struct{}{}
+	}
 }
 
 type minHeap []api.PerconaServerMongoDBBackup
@@ -279,7 +470,7 @@ func (r *ReconcilePerconaServerMongoDB) isRestoreRunning(ctx context.Context, cr
 
 	for _, rst := range restores.Items {
 		if rst.Status.State != api.RestoreStateReady && rst.Status.State != api.RestoreStateNew && rst.Status.State != api.RestoreStateError &&
-			rst.Spec.ClusterName == cr.Name {
+			rst.Spec.ClusterName == cr.Name && !rst.Spec.DryRun {
 			return true, nil
 		}
 	}
@@ -317,6 +508,11 @@ func (r *ReconcilePerconaServerMongoDB) hasFullBackup(ctx context.Context, cr *a
 	}
 
 	for _, b := range backups.Items {
+		// PITR oplog is based only on full backups in the main storage
+		if cr.Spec.Backup.StorageProfile(b.Spec.StorageName) != "" || b.Status.IsSelective() {
+			continue
+		}
+
 		if b.Status.State == api.BackupStateReady && b.Spec.GetClusterName() == cr.Name {
 			return true, nil
 		}
@@ -342,6 +538,10 @@ func getLatestBackup(ctx context.Context, cl client.Client, cr *api.PerconaServe
 			continue
 		}
 
+		if cr.Spec.Backup.StorageProfile(b.Spec.StorageName) != "" || b.Status.IsSelective() {
+			continue
+		}
+
 		if latest == nil || latest.CreationTimestamp.Before(&b.ObjectMeta.CreationTimestamp) {
 			latest = &b
 		}
@@ -353,6 +553,10 @@ func getLatestBackup(ctx context.Context, cl client.Client, cr *api.PerconaServe
 func (r *ReconcilePerconaServerMongoDB) updatePITR(ctx context.Context, cr *api.PerconaServerMongoDB) error {
 	log := logf.FromContext(ctx)
 
+	if !cr.Spec.Backup.Enabled || !cr.Spec.Backup.PITR.Enabled {
+		metrics.DeletePITR(cr.Namespace, cr.Name)
+	}
+
 	if !cr.Spec.Backup.Enabled {
 		return nil
 	}
@@ -396,14 +600,9 @@ func (r *ReconcilePerconaServerMongoDB) updatePITR(ctx context.Context, cr *api.
 			return errors.Wrap(err, "get pitr.enabled")
 		}
 
-		if len(cr.Spec.Backup.Storages) == 1 {
-			// if PiTR is enabled user can configure only one storage
-			var storage api.BackupStorageSpec
-			for name, stg := range cr.Spec.Backup.Storages {
-				storage = stg
-				log.Info("Configuring PBM with storage", "storage", name)
-				break
-			}
+		if name, storage, ok := cr.Spec.Backup.MainStorage(); ok {
+			// if PiTR is enabled oplog chunks are stored in the main storage
+			log.Info("Configuring PBM with storage", "storage", name)
 
 			var secretName string
 			switch storage.Type {
@@ -441,6 +640,16 @@ func (r *ReconcilePerconaServerMongoDB) updatePITR(ctx context.Context, cr *api.
 		return errors.Errorf("unexpected value of pitr.enabled: %T", val)
 	}
 
+	if len(cr.Spec.Backup.Storages) > 1 {
+		if err := r.ensurePBMMainStorage(ctx, pbm, cr); err != nil {
+			return errors.Wrap(err, "ensure PBM main storage")
+		}
+	}
+
+	if err := r.reconcilePBMPriorities(ctx, pbm, cr); err != nil {
+		return errors.Wrap(err, "reconcile PBM priorities")
+	}
+
 	if enabled != cr.Spec.Backup.PITR.Enabled {
 		val := strconv.FormatBool(cr.Spec.Backup.PITR.Enabled)
 		log.Info("Setting pitr.enabled in PBM config", "enabled", val)
@@ -593,6 +802,80 @@ func (r *ReconcilePerconaServerMongoDB) updatePITR(ctx context.Context, cr *api.
 		return errors.Wrap(err, "update latest restorable time")
 	}
 
+	if err := r.updatePITRWindow(ctx, pbm, cr); err != nil {
+		return errors.Wrap(err, "update PITR window")
+	}
+
+	return nil
+}
+
+// ensurePBMMainStorage switches PBM main storage back to the storage marked
+// as main, e.g. after a restore or a backup to another storage changed it.
+func (r *ReconcilePerconaServerMongoDB) ensurePBMMainStorage(ctx context.Context, pbm backup.PBM, cr *api.PerconaServerMongoDB) error {
+	name, stg, ok := cr.Spec.Backup.MainStorage()
+	if !ok {
+		return nil
+	}
+
+	cfg, err := pbm.GetConfig(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get pbm config")
+	}
+
+	expected, err := backup.GetPBMConfig(ctx, r.client, cr, stg)
+	if err != nil {
+		return errors.Wrap(err, "get expected pbm config")
+	}
+
+	// PBM fills defaults of the stored config
+	if err := expected.Storage.Cast(); err != nil {
+		return errors.Wrap(err, "cast expected storage config")
+	}
+
+	if cfg.Storage.Equal(&expected.Storage) {
+		return nil
+	}
+
+	logf.FromContext(ctx).Info("Switching PBM main storage", "storage", name)
+
+	if err := pbm.GetNSetConfig(ctx, r.client, cr, stg); err != nil {
+		return errors.Wrap(err, "set PBM config")
+	}
+
+	return nil
+}
+
+// reconcilePBMPriorities updates PBM priorities translated from priority rules,
+// since members matching the rules change with the cluster topology.
+func (r *ReconcilePerconaServerMongoDB) reconcilePBMPriorities(ctx context.Context, pbm backup.PBM, cr *api.PerconaServerMongoDB) error {
+	opts := cr.Spec.Backup.Configuration.BackupOptions
+	if opts == nil || len(opts.PriorityRules) == 0 {
+		return nil
+	}
+
+	priorities, err := backup.GetPriorities(ctx, r.client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get priorities")
+	}
+
+	cfg, err := pbm.GetConfig(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get pbm config")
+	}
+
+	if cfg.Backup == nil {
+		cfg.Backup = &config.BackupConf{}
+	} else if maps.Equal(cfg.Backup.Priority, priorities) {
+		return nil
+	}
+
+	logf.FromContext(ctx).Info("Updating PBM backup priorities", "priorities", priorities)
+
+	cfg.Backup.Priority = priorities
+	if err := pbm.SetConfig(ctx, cfg); err != nil {
+		return errors.Wrap(err, "set pbm config")
+	}
+
 	return nil
 }
 
@@ -617,6 +900,8 @@ func updateLatestRestorableTime(ctx context.Context, cl client.Client, pbm backu
 		return nil
 	}
 
+	metrics.SetPITRLatestRestorableTime(cr.Namespace, cr.Name, time.Unix(int64(tl.End), 0))
+
 	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		b := new(api.PerconaServerMongoDBBackup)
 		if err := cl.Get(ctx, types.NamespacedName{Name: bcp.Name, Namespace: bcp.Namespace}, b); err != nil {
@@ -645,6 +930,16 @@ func (r *ReconcilePerconaServerMongoDB) resyncPBMIfNeeded(ctx context.Context, c
 		return nil
 	}
 
+	// physical restore brings back PBM config from the backup,
+	// so storages should be configured again before resync
+	_, _, hasMain := cr.Spec.Backup.MainStorage()
+	hasProfiles := hasMain && len(cr.Spec.Backup.Storages) > 1
+	if hasProfiles {
+		if err := r.setPBMStorageProfiles(ctx, cr); err != nil {
+			return errors.Wrap(err, "set PBM storage profiles")
+		}
+	}
+
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		c := &api.PerconaServerMongoDB{}
 		err := r.client.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, c)
@@ -654,6 +949,9 @@ func (r *ReconcilePerconaServerMongoDB) resyncPBMIfNeeded(ctx context.Context, c
 
 		orig := c.DeepCopy()
 		delete(c.Annotations, api.AnnotationResyncPBM)
+		if cr.Spec.Backup.ImportBackups {
+			c.Annotations[api.AnnotationImportBackups] = time.Now().UTC().Format(time.RFC3339)
+		}
 
 		return r.client.Patch(ctx, c, client.MergeFrom(orig))
 	})
@@ -680,6 +978,47 @@ func (r *ReconcilePerconaServerMongoDB) resyncPBMIfNeeded(ctx context.Context, c
 		return errors.Wrapf(err, "start PBM resync: run %v", command)
 	}
 
+	if !hasProfiles {
+		return nil
+	}
+
+	stdoutBuffer.Reset()
+	stderrBuffer.Reset()
+	command = []string{"pbm", "profile", "sync", "--all"}
+	log.Info("Starting PBM profiles resync", "command", command)
+
+	err = r.clientcmd.Exec(ctx, pod, naming.ContainerBackupAgent, command, nil, &stdoutBuffer, &stderrBuffer, false)
+	if err != nil {
+		return errors.Wrapf(err, "start PBM profiles resync: run %v", command)
+	}
+
+	return nil
+}
+
+// setPBMStorageProfiles sets PBM main storage to the storage marked as main
+// and saves config profiles for the rest of storages.
+func (r *ReconcilePerconaServerMongoDB) setPBMStorageProfiles(ctx context.Context, cr *api.PerconaServerMongoDB) error {
+	pbm, err := backup.NewPBM(ctx, r.client, cr)
+	if err != nil {
+		return errors.Wrap(err, "create pbm object")
+	}
+	defer pbm.Close(ctx)
+
+	if err := r.ensurePBMMainStorage(ctx, pbm, cr); err != nil {
+		return errors.Wrap(err, "ensure PBM main storage")
+	}
+
+	for name, stg := range cr.Spec.Backup.Storages {
+		profile := cr.Spec.Backup.StorageProfile(name)
+		if profile == "" {
+			continue
+		}
+
+		if err := pbm.GetNSetProfile(ctx, r.client, cr, profile, stg); err != nil {
+			return errors.Wrapf(err, "set PBM config profile for storage %s", name)
+		}
+	}
+
 	return nil
 }
 
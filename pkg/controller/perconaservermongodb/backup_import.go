@@ -0,0 +1,269 @@
+package perconaservermongodb
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/storage"
+	"github.com/percona/percona-backup-mongodb/pbm/util"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+)
+
+// importBackupsDelay is the time given to PBM agents to pick up the resync command
+// before the operator starts to wait for the resync lock to be released.
+const importBackupsDelay = 30 * time.Second
+
+// importBackupsIfNeeded creates backup objects for completed backups known by PBM
+// that don't have a backup object yet. It runs when the cluster has the
+// percona.com/import-backups annotation, which is set after PBM resync if
+// spec.backup.importBackups is enabled or can be set manually.
+func (r *ReconcilePerconaServerMongoDB) importBackupsIfNeeded(ctx context.Context, cr *api.PerconaServerMongoDB) error {
+	log := logf.FromContext(ctx)
+
+	if cr.Status.State != api.AppStateReady || !cr.Spec.Backup.Enabled {
+		return nil
+	}
+
+	requested, ok := cr.Annotations[api.AnnotationImportBackups]
+	if !ok {
+		return nil
+	}
+
+	if _, resyncNeeded := cr.Annotations[api.AnnotationResyncPBM]; resyncNeeded {
+		return nil
+	}
+
+	if ts, err := time.Parse(time.RFC3339, requested); err == nil && time.Since(ts) < importBackupsDelay {
+		return nil
+	}
+
+	pbm, err := r.newPBM(ctx, r.client, cr)
+	if err != nil {
+		return errors.Wrap(err, "create pbm object")
+	}
+	defer pbm.Close(ctx)
+
+	resyncRunning, err := pbm.HasLocks(ctx, backup.IsResyncLock)
+	if err != nil {
+		return errors.Wrap(err, "check resync lock")
+	}
+	if resyncRunning {
+		log.V(1).Info("Waiting for PBM resync to finish before importing backups")
+		return nil
+	}
+
+	metas, err := pbm.GetDoneBackups(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get backups from pbm")
+	}
+
+	bcps := &api.PerconaServerMongoDBBackupList{}
+	if err := r.client.List(ctx, bcps, &client.ListOptions{Namespace: cr.Namespace}); err != nil {
+		return errors.Wrap(err, "list backups")
+	}
+
+	existing := make(map[string]struct{})
+	for _, b := range bcps.Items {
+		if b.Spec.GetClusterName() == cr.Name && b.Status.PBMname != "" {
+			existing[b.Status.PBMname] = struct{}{}
+		}
+	}
+
+	imported := 0
+	for i := range metas {
+		meta := &metas[i]
+		if _, ok := existing[meta.Name]; ok {
+			continue
+		}
+
+		switch meta.Type {
+		case defs.LogicalBackup, defs.PhysicalBackup, defs.IncrementalBackup:
+		default:
+			log.Info("Skipping backup import, backup type is not supported", "pbmName", meta.Name, "type", meta.Type)
+			continue
+		}
+
+		stgName, err := r.backupStorageName(ctx, cr, meta)
+		if err != nil {
+			return errors.Wrapf(err, "get storage of backup %s", meta.Name)
+		}
+		if stgName == "" {
+			log.Info("Skipping backup import, storage is not found in spec.backup.storages", "pbmName", meta.Name, "storage", meta.Store.Path())
+			continue
+		}
+
+		if err := r.importBackup(ctx, cr, stgName, meta); err != nil {
+			return errors.Wrapf(err, "import backup %s", meta.Name)
+		}
+		imported++
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		c := &api.PerconaServerMongoDB{}
+		err := r.client.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, c)
+		if err != nil {
+			return err
+		}
+
+		orig := c.DeepCopy()
+		delete(c.Annotations, api.AnnotationImportBackups)
+
+		return r.client.Patch(ctx, c, client.MergeFrom(orig))
+	})
+	if err != nil {
+		return errors.Wrap(err, "delete annotation")
+	}
+
+	log.Info("Backups are imported", "imported", imported, "known", len(metas))
+
+	return nil
+}
+
+func (r *ReconcilePerconaServerMongoDB) importBackup(ctx context.Context, cr *api.PerconaServerMongoDB, stgName string, meta *backup.BackupMeta) error {
+	log := logf.FromContext(ctx)
+
+	bcp := &api.PerconaServerMongoDBBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      importedBackupName(cr.Name, meta.Name),
+			Namespace: cr.Namespace,
+			Labels:    naming.ImportedBackupLabels(cr),
+		},
+		Spec: api.PerconaServerMongoDBBackupSpec{
+			ClusterName: cr.Name,
+			StorageName: stgName,
+			Type:        meta.Type,
+			Compression: meta.Compression,
+			Namespaces:  importedNamespaces(meta),
+		},
+	}
+
+	err := r.client.Create(ctx, bcp)
+	if k8serrors.IsAlreadyExists(err) {
+		if err := r.client.Get(ctx, client.ObjectKeyFromObject(bcp), bcp); err != nil {
+			return errors.Wrap(err, "get backup")
+		}
+		if bcp.Labels[naming.LabelBackupType] != naming.BackupTypeImported || bcp.Status.State != api.BackupStateNew {
+			log.Info("Skipping backup import, backup object already exists", "backup", bcp.Name, "pbmName", meta.Name)
+			return nil
+		}
+	} else if err != nil {
+		return errors.Wrap(err, "create backup")
+	}
+
+	bcp.Status = importedBackupStatus(stgName, cr.Spec.Backup.Storages[stgName], meta)
+	if err := r.client.Status().Update(ctx, bcp); err != nil {
+		return errors.Wrap(err, "update backup status")
+	}
+
+	log.Info("Backup is imported", "backup", bcp.Name, "pbmName", meta.Name, "storage", stgName)
+
+	return nil
+}
+
+// backupStorageName returns the name of the storage from spec.backup.storages
+// the backup is stored in. It's empty if no storage matches the backup.
+func (r *ReconcilePerconaServerMongoDB) backupStorageName(ctx context.Context, cr *api.PerconaServerMongoDB, meta *backup.BackupMeta) (string, error) {
+	if meta.Store.IsProfile {
+		if _, ok := cr.Spec.Backup.Storages[meta.Store.Name]; ok {
+			return meta.Store.Name, nil
+		}
+		return "", nil
+	}
+
+	names := make([]string, 0, len(cr.Spec.Backup.Storages))
+	for name := range cr.Spec.Backup.Storages {
+		if cr.Spec.Backup.StorageProfile(name) == "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cfg, err := backup.GetPBMConfig(ctx, r.client, cr, cr.Spec.Backup.Storages[name])
+		if err != nil {
+			return "", errors.Wrapf(err, "get pbm config for storage %s", name)
+		}
+
+		// PBM fills defaults of the stored config
+		if err := cfg.Storage.Cast(); err != nil {
+			return "", errors.Wrapf(err, "cast storage config %s", name)
+		}
+
+		if cfg.Storage.Equal(&meta.Store.StorageConf) {
+			return name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// importedBackupName returns a valid object name for the imported backup:
+// the PBM backup name is a timestamp, which contains uppercase letters and colons.
+func importedBackupName(clusterName, pbmName string) string {
+	return clusterName + "-" + strings.NewReplacer(":", "-", ".", "-").Replace(strings.ToLower(pbmName))
+}
+
+func importedNamespaces(meta *backup.BackupMeta) []string {
+	if !util.IsSelective(meta.Namespaces) {
+		return nil
+	}
+	return meta.Namespaces
+}
+
+func importedBackupStatus(stgName string, stg api.BackupStorageSpec, meta *backup.BackupMeta) api.PerconaServerMongoDBBackupStatus {
+	status := api.PerconaServerMongoDBBackupStatus{
+		Type:         meta.Type,
+		State:        api.BackupStateReady,
+		StorageName:  stgName,
+		PBMname:      meta.Name,
+		Destination:  backup.Destination(stg, meta.Name),
+		SrcBackup:    meta.SrcBackup,
+		MongoVersion: meta.MongoVersion,
+		FCV:          meta.FCV,
+		Namespaces:   importedNamespaces(meta),
+		PBMPods:      make(map[string]string),
+		StartAt:      &metav1.Time{Time: time.Unix(meta.StartTS, 0)},
+		CompletedAt:  &metav1.Time{Time: time.Unix(meta.LastTransitionTS, 0)},
+		LastTransition: &metav1.Time{
+			Time: time.Unix(meta.LastTransitionTS, 0),
+		},
+		Duration: &metav1.Duration{
+			Duration: time.Duration(meta.LastTransitionTS-meta.StartTS) * time.Second,
+		},
+	}
+
+	if meta.Size > 0 {
+		status.SizeBytes = meta.Size
+		status.Size = storage.PrettySize(meta.Size)
+	}
+
+	for _, rs := range meta.Replsets {
+		status.ReplsetNames = append(status.ReplsetNames, rs.Name)
+		status.PBMPods[rs.Name] = rs.Node
+	}
+
+	switch stg.Type {
+	case api.BackupStorageS3:
+		status.S3 = &stg.S3
+	case api.BackupStorageAzure:
+		status.Azure = &stg.Azure
+	case api.BackupStorageFilesystem:
+		status.Filesystem = &stg.Filesystem
+	}
+
+	return status
+}
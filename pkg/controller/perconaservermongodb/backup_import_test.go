@@ -0,0 +1,66 @@
+package perconaservermongodb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	pbmBackup "github.com/percona/percona-backup-mongodb/pbm/backup"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+)
+
+func TestImportedBackupName(t *testing.T) {
+	if name := importedBackupName("my-cluster", "2024-05-10T12:00:00Z"); name != "my-cluster-2024-05-10t12-00-00z" {
+		t.Errorf("unexpected name %s", name)
+	}
+}
+
+func TestImportedBackupStatus(t *testing.T) {
+	stg := api.BackupStorageSpec{
+		Type: api.BackupStorageS3,
+		S3: api.BackupStorageS3Spec{
+			Bucket: "bucket",
+			Prefix: "prefix",
+		},
+	}
+	meta := &backup.BackupMeta{
+		Name:             "2024-05-10T12:00:00Z",
+		Type:             defs.LogicalBackup,
+		Namespaces:       []string{"app.*"},
+		Size:             2048,
+		MongoVersion:     "7.0.8",
+		FCV:              "7.0",
+		StartTS:          1715342400,
+		LastTransitionTS: 1715342460,
+		Replsets: []pbmBackup.BackupReplset{
+			{Name: "rs0", Node: "cluster-rs0-1.cluster-rs0.ns.svc.cluster.local:27017"},
+		},
+	}
+
+	status := importedBackupStatus("s3", stg, meta)
+
+	if status.State != api.BackupStateReady {
+		t.Errorf("unexpected state %s", status.State)
+	}
+	if status.Destination != "s3://bucket/prefix/2024-05-10T12:00:00Z" {
+		t.Errorf("unexpected destination %s", status.Destination)
+	}
+	if status.S3 == nil || status.S3.Bucket != "bucket" {
+		t.Errorf("unexpected s3 storage %v", status.S3)
+	}
+	if status.Duration.Duration != time.Minute {
+		t.Errorf("unexpected duration %s", status.Duration.Duration)
+	}
+	if status.Size != "2.00KB" || status.SizeBytes != 2048 {
+		t.Errorf("unexpected size %s (%d)", status.Size, status.SizeBytes)
+	}
+	if !reflect.DeepEqual(status.ReplsetNames, []string{"rs0"}) {
+		t.Errorf("unexpected replsets %v", status.ReplsetNames)
+	}
+	if !reflect.DeepEqual(status.Namespaces, []string{"app.*"}) {
+		t.Errorf("unexpected namespaces %v", status.Namespaces)
+	}
+}
@@ -0,0 +1,294 @@
+package perconaservermongodb
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+)
+
+func TestExpiredBackups(t *testing.T) {
+	now := time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC)
+
+	// one ready backup per day for the last 100 days: day-0 is the newest
+	var bcps []api.PerconaServerMongoDBBackup
+	for i := 0; i < 100; i++ {
+		bcps = append(bcps, api.PerconaServerMongoDBBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "day-" + strconv.Itoa(i),
+				CreationTimestamp: metav1.NewTime(now.Add(-time.Duration(i) * 24 * time.Hour)),
+			},
+			Status: api.PerconaServerMongoDBBackupStatus{
+				State: api.BackupStateReady,
+			},
+		})
+	}
+	failed := api.PerconaServerMongoDBBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "failed",
+			CreationTimestamp: metav1.NewTime(now.Add(-200 * 24 * time.Hour)),
+		},
+		Status: api.PerconaServerMongoDBBackupStatus{
+			State: api.BackupStateError,
+		},
+	}
+	bcps = append(bcps, failed)
+
+	recentFailed := failed.DeepCopy()
+	recentFailed.Name = "recent-failed"
+	recentFailed.CreationTimestamp = metav1.NewTime(now.Add(-12 * time.Hour))
+
+	stuck := failed.DeepCopy()
+	stuck.Name = "stuck"
+	stuck.Status.State = api.BackupStateRunning
+
+	tests := []struct {
+		name      string
+		keep      int
+		retention *api.BackupRetention
+		bcps      []api.PerconaServerMongoDBBackup
+
+		expectedRetained int
+		expectedStale    []string
+	}{
+		{
+			name:             "max age",
+			retention:        &api.BackupRetention{MaxAge: &metav1.Duration{Duration: 30 * 24 * time.Hour}},
+			bcps:             bcps,
+			expectedRetained: 31,
+			expectedStale:    []string{"failed"},
+		},
+		{
+			name:             "daily",
+			retention:        &api.BackupRetention{Daily: 7},
+			bcps:             bcps,
+			expectedRetained: 7,
+			expectedStale:    []string{"failed"},
+		},
+		{
+			name:      "daily, weekly and monthly",
+			retention: &api.BackupRetention{Daily: 7, Weekly: 4, Monthly: 12},
+			bcps:      bcps,
+			// 7 dailies (Mar 25-31), 3 more weeklies (Mar 24, 17, 10)
+			// and 3 more monthlies (Feb 29, Jan 31, Dec 31)
+			expectedRetained: 13,
+			expectedStale:    []string{"failed"},
+		},
+		{
+			name:             "keep with max age",
+			keep:             3,
+			retention:        &api.BackupRetention{MaxAge: &metav1.Duration{Duration: 36 * time.Hour}},
+			bcps:             bcps,
+			expectedRetained: 2,
+			expectedStale:    []string{"failed"},
+		},
+		{
+			name:             "failed backup within retention",
+			retention:        &api.BackupRetention{Daily: 7},
+			bcps:             append([]api.PerconaServerMongoDBBackup{*recentFailed}, bcps[:10]...),
+			expectedRetained: 7,
+		},
+		{
+			name:             "stuck backup",
+			retention:        &api.BackupRetention{Daily: 7},
+			bcps:             append([]api.PerconaServerMongoDBBackup{*stuck}, bcps[:10]...),
+			expectedRetained: 7,
+			expectedStale:    []string{"stuck"},
+		},
+		{
+			name:             "latest backup is always retained",
+			retention:        &api.BackupRetention{MaxAge: &metav1.Duration{Duration: time.Hour}},
+			bcps:             bcps[10:20],
+			expectedRetained: 1,
+		},
+		{
+			name:             "no ready backups",
+			retention:        &api.BackupRetention{Daily: 1},
+			bcps:             []api.PerconaServerMongoDBBackup{failed},
+			expectedRetained: 0,
+		},
+		{
+			name:             "no ready backups with max age",
+			retention:        &api.BackupRetention{MaxAge: &metav1.Duration{Duration: 30 * 24 * time.Hour}},
+			bcps:             []api.PerconaServerMongoDBBackup{*recentFailed, failed},
+			expectedRetained: 0,
+			expectedStale:    []string{"failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready := 0
+			for _, b := range tt.bcps {
+				if b.Status.State == api.BackupStateReady {
+					ready++
+				}
+			}
+
+			var stale []string
+			var expired []api.PerconaServerMongoDBBackup
+			for _, b := range expiredBackups(tt.bcps, tt.keep, tt.retention, now) {
+				if b.Status.State != api.BackupStateReady {
+					stale = append(stale, b.Name)
+					continue
+				}
+				expired = append(expired, b)
+			}
+
+			if retained := ready - len(expired); retained != tt.expectedRetained {
+				t.Fatalf("expected %d retained backups, got %d", tt.expectedRetained, retained)
+			}
+			if !reflect.DeepEqual(stale, tt.expectedStale) {
+				t.Fatalf("expected %v stale backups, got %v", tt.expectedStale, stale)
+			}
+
+			if len(expired) == 0 {
+				return
+			}
+
+			sort.Slice(expired, func(i, j int) bool {
+				return expired[j].CreationTimestamp.Before(&expired[i].CreationTimestamp)
+			})
+			if expired[0].Name == tt.bcps[0].Name {
+				t.Fatal("latest backup must be retained")
+			}
+		})
+	}
+}
+
+func TestKeepIncrementalChains(t *testing.T) {
+	bcp := func(name, pbmName, src string, state api.BackupState) api.PerconaServerMongoDBBackup {
+		return api.PerconaServerMongoDBBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: api.PerconaServerMongoDBBackupStatus{
+				State:     state,
+				Type:      defs.IncrementalBackup,
+				PBMname:   pbmName,
+				SrcBackup: src,
+			},
+		}
+	}
+
+	full := api.PerconaServerMongoDBBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "full"},
+		Status:     api.PerconaServerMongoDBBackupStatus{State: api.BackupStateReady, Type: defs.PhysicalBackup},
+	}
+	base := bcp("base", "2024-03-01T00:00:00Z", "", api.BackupStateReady)
+	inc1 := bcp("inc1", "2024-03-02T00:00:00Z", base.Status.PBMname, api.BackupStateReady)
+	inc2 := bcp("inc2", "2024-03-03T00:00:00Z", inc1.Status.PBMname, api.BackupStateReady)
+	failed := bcp("failed", "2024-03-04T00:00:00Z", inc2.Status.PBMname, api.BackupStateError)
+	orphan := bcp("orphan", "2024-03-05T00:00:00Z", "2024-02-01T00:00:00Z", api.BackupStateReady)
+
+	bcps := []api.PerconaServerMongoDBBackup{full, base, inc1, inc2, failed, orphan}
+
+	tests := map[string]struct {
+		expired  []api.PerconaServerMongoDBBackup
+		expected []string
+	}{
+		"full backup": {
+			expired:  []api.PerconaServerMongoDBBackup{full},
+			expected: []string{"full"},
+		},
+		"whole chain": {
+			expired:  []api.PerconaServerMongoDBBackup{base, inc1, inc2},
+			expected: []string{"base", "inc1", "inc2"},
+		},
+		"base with retained increment": {
+			expired: []api.PerconaServerMongoDBBackup{base, inc1},
+		},
+		"increment without base": {
+			expired: []api.PerconaServerMongoDBBackup{inc2},
+		},
+		"increment of chain not in the list": {
+			expired: []api.PerconaServerMongoDBBackup{orphan},
+		},
+		"failed increment": {
+			expired:  []api.PerconaServerMongoDBBackup{failed},
+			expected: []string{"failed"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, b := range keepIncrementalChains(bcps, tt.expired) {
+				got = append(got, b.Name)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPITRBaseBackups(t *testing.T) {
+	ctx := context.Background()
+
+	cr := &api.PerconaServerMongoDB{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "ns"}}
+	cr.Spec.Backup.Storages = map[string]api.BackupStorageSpec{
+		"main":  {Main: true},
+		"other": {},
+	}
+
+	bcp := func(name, storage string, created time.Time, namespaces []string) *api.PerconaServerMongoDBBackup {
+		return &api.PerconaServerMongoDBBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", CreationTimestamp: metav1.NewTime(created)},
+			Spec:       api.PerconaServerMongoDBBackupSpec{ClusterName: "cluster", StorageName: storage},
+			Status:     api.PerconaServerMongoDBBackupStatus{State: api.BackupStateReady, Namespaces: namespaces},
+		}
+	}
+	now := time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		backups []client.Object
+		latest  string
+	}{
+		"no backups": {},
+		"only selective backups": {
+			backups: []client.Object{bcp("selective", "main", now, []string{"app.*"})},
+		},
+		"selective backup is newer": {
+			backups: []client.Object{
+				bcp("full", "main", now.Add(-time.Hour), nil),
+				bcp("selective", "main", now, []string{"app.*"}),
+				bcp("other-storage", "other", now, nil),
+			},
+			latest: "full",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := buildFakeClient(tt.backups...)
+
+			has, err := r.hasFullBackup(ctx, cr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if has != (tt.latest != "") {
+				t.Errorf("expected full backup %t, got %t", tt.latest != "", has)
+			}
+
+			latest, err := getLatestBackup(ctx, r.client, cr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			if latest != nil {
+				got = latest.Name
+			}
+			if got != tt.latest {
+				t.Errorf("expected latest backup %q, got %q", tt.latest, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package perconaservermongodb
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+)
+
+func dataSourceRestoreName(cr *api.PerconaServerMongoDB) string {
+	return cr.Name + "-data-source"
+}
+
+// reconcileDataSource restores spec.dataSource into the cluster once its replsets are initialized
+// and reports the restore in status.dataSource. The cluster isn't ready until the restore is finished.
+func (r *ReconcilePerconaServerMongoDB) reconcileDataSource(ctx context.Context, cr *api.PerconaServerMongoDB) error {
+	log := logf.FromContext(ctx)
+
+	if !cr.DataSourcePending() {
+		return nil
+	}
+
+	status := cr.Status.DataSource
+	if status == nil {
+		if !replsetsInitialized(cr) {
+			log.V(1).Info("Waiting for replsets to be initialized to restore data source")
+			return nil
+		}
+
+		status = &api.DataSourceStatus{Restore: dataSourceRestoreName(cr)}
+		cr.Status.DataSource = status
+	}
+
+	restore := new(api.PerconaServerMongoDBRestore)
+	err := r.client.Get(ctx, types.NamespacedName{Name: status.Restore, Namespace: cr.Namespace}, restore)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "get restore %s", status.Restore)
+		}
+
+		// deleting the restore object restarts the restore, e.g. to retry the failed one
+		if status.State != api.RestoreStateNew {
+			log.Info("Restore of data source is deleted, restarting it", "restore", status.Restore, "state", status.State)
+			*status = api.DataSourceStatus{Restore: status.Restore}
+		}
+
+		restore = newDataSourceRestore(cr)
+		if err := setControllerReference(cr, restore, r.scheme); err != nil {
+			return errors.Wrap(err, "set controller reference")
+		}
+		if err := r.client.Create(ctx, restore); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "create restore %s", restore.Name)
+		}
+		log.Info("Restoring data source", "restore", restore.Name, "backup", restore.Spec.BackupName)
+
+		return nil
+	}
+
+	// the failure is already reported, the cluster waits until the failed restore is deleted
+	if status.State == api.RestoreStateError {
+		return nil
+	}
+
+	status.State = restore.Status.State
+	status.Error = restore.Status.Error
+
+	switch restore.Status.State {
+	case api.RestoreStateReady:
+		status.CompletedAt = restore.Status.CompletedAt
+		if status.CompletedAt == nil {
+			now := metav1.Now()
+			status.CompletedAt = &now
+		}
+		log.Info("Data source is restored", "restore", restore.Name)
+	case api.RestoreStateError, api.RestoreStateRejected:
+		status.State = api.RestoreStateError
+
+		msg := fmt.Sprintf("restore %s of data source failed: %s", status.Restore, status.Error)
+		log.Info("Data source is not restored, delete the restore object to retry", "restore", restore.Name, "error", status.Error)
+		cr.Status.AddCondition(api.ClusterCondition{
+			Status:             api.ConditionTrue,
+			Type:               api.AppStateError,
+			Reason:             "DataSourceRestoreFailed",
+			Message:            msg,
+			LastTransitionTime: metav1.Now(),
+		})
+	}
+
+	return nil
+}
+
+func newDataSourceRestore(cr *api.PerconaServerMongoDB) *api.PerconaServerMongoDBRestore {
+	ds := cr.Spec.DataSource
+
+	return &api.PerconaServerMongoDBRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dataSourceRestoreName(cr),
+			Namespace: cr.Namespace,
+			Labels:    naming.ClusterLabels(cr),
+		},
+		Spec: api.PerconaServerMongoDBRestoreSpec{
+			ClusterName:      cr.Name,
+			BackupName:       ds.BackupName,
+			BackupSource:     ds.BackupSource.DeepCopy(),
+			StorageName:      ds.StorageName,
+			PITR:             ds.PITR.DeepCopy(),
+			ReplsetRemapping: maps.Clone(ds.ReplsetRemapping),
+		},
+	}
+}
+
+// replsetsInitialized returns true if all replsets and mongos are ready
+// and the cluster is waiting only for the data source to be restored.
+func replsetsInitialized(cr *api.PerconaServerMongoDB) bool {
+	if cr.Status.Host == "" {
+		return false
+	}
+
+	repls := cr.Spec.Replsets
+	if cr.Spec.Sharding.Enabled && cr.Spec.Sharding.ConfigsvrReplSet != nil {
+		repls = append([]*api.ReplsetSpec{cr.Spec.Sharding.ConfigsvrReplSet}, repls...)
+	}
+
+	for _, rs := range repls {
+		status, ok := cr.Status.Replsets[rs.Name]
+		if !ok || !status.Initialized || status.Status != api.AppStateReady {
+			return false
+		}
+	}
+
+	if cr.Spec.Sharding.Enabled && (cr.Status.Mongos == nil || cr.Status.Mongos.Status != api.AppStateReady) {
+		return false
+	}
+
+	return true
+}
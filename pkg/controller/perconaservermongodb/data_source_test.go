@@ -0,0 +1,131 @@
+package perconaservermongodb
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+)
+
+func TestReconcileDataSource(t *testing.T) {
+	ctx := context.Background()
+
+	cr := &api.PerconaServerMongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "preview", Namespace: "psmdb"},
+		Spec: api.PerconaServerMongoDBSpec{
+			Replsets: []*api.ReplsetSpec{{Name: "rs0", Size: 3}},
+			DataSource: &api.DataSourceSpec{
+				BackupName: "prod-backup",
+				PITR:       &api.PITRestoreSpec{Type: api.PITRestoreTypeLatest},
+			},
+		},
+		Status: api.PerconaServerMongoDBStatus{
+			Replsets: map[string]api.ReplsetStatus{
+				"rs0": {Initialized: false, Status: api.AppStateInit},
+			},
+		},
+	}
+
+	r := buildFakeClient(cr)
+
+	if err := r.reconcileDataSource(ctx, cr); err != nil {
+		t.Fatal(err)
+	}
+	if cr.Status.DataSource != nil {
+		t.Fatal("restore is started before replsets are initialized")
+	}
+
+	cr.Status.Host = "preview-rs0.psmdb.svc.cluster.local"
+	cr.Status.Replsets["rs0"] = api.ReplsetStatus{Initialized: true, Status: api.AppStateReady}
+
+	if err := r.reconcileDataSource(ctx, cr); err != nil {
+		t.Fatal(err)
+	}
+	if cr.Status.DataSource == nil || cr.Status.DataSource.Restore != "preview-data-source" {
+		t.Fatalf("unexpected data source status %v", cr.Status.DataSource)
+	}
+
+	restore := new(api.PerconaServerMongoDBRestore)
+	if err := r.client.Get(ctx, types.NamespacedName{Name: "preview-data-source", Namespace: cr.Namespace}, restore); err != nil {
+		t.Fatal(err)
+	}
+	if restore.Spec.ClusterName != cr.Name || restore.Spec.BackupName != "prod-backup" || restore.Spec.PITR == nil {
+		t.Errorf("unexpected restore spec %v", restore.Spec)
+	}
+	if len(restore.OwnerReferences) != 1 || restore.OwnerReferences[0].Name != cr.Name {
+		t.Errorf("unexpected owner references %v", restore.OwnerReferences)
+	}
+	if !cr.DataSourcePending() {
+		t.Error("data source is not pending while restore is running")
+	}
+
+	restore.Status.State = api.RestoreStateReady
+	if err := r.client.Update(ctx, restore); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.reconcileDataSource(ctx, cr); err != nil {
+		t.Fatal(err)
+	}
+	if cr.DataSourcePending() || cr.Status.DataSource.CompletedAt == nil {
+		t.Errorf("data source is not restored: %v", cr.Status.DataSource)
+	}
+
+	if err := r.client.Delete(ctx, restore); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcileDataSource(ctx, cr); err != nil {
+		t.Errorf("data source is restored again: %v", err)
+	}
+}
+
+func TestReconcileDataSourceFailed(t *testing.T) {
+	ctx := context.Background()
+
+	cr := &api.PerconaServerMongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "preview", Namespace: "psmdb"},
+		Spec: api.PerconaServerMongoDBSpec{
+			Replsets:   []*api.ReplsetSpec{{Name: "rs0", Size: 3}},
+			DataSource: &api.DataSourceSpec{BackupName: "prod-backup"},
+		},
+		Status: api.PerconaServerMongoDBStatus{
+			DataSource: &api.DataSourceStatus{Restore: "preview-data-source"},
+		},
+	}
+	restore := newDataSourceRestore(cr)
+	restore.Status = api.PerconaServerMongoDBRestoreStatus{
+		State: api.RestoreStateError,
+		Error: "backup is not found",
+	}
+
+	r := buildFakeClient(cr, restore)
+
+	for i := 0; i < 2; i++ {
+		if err := r.reconcileDataSource(ctx, cr); err != nil {
+			t.Fatalf("failed restore blocks reconcile: %v", err)
+		}
+	}
+	if cr.Status.DataSource.State != api.RestoreStateError {
+		t.Errorf("unexpected state %s", cr.Status.DataSource.State)
+	}
+	if len(cr.Status.Conditions) != 1 || cr.Status.Conditions[0].Reason != "DataSourceRestoreFailed" ||
+		cr.Status.Conditions[0].Message != "restore preview-data-source of data source failed: backup is not found" {
+		t.Errorf("unexpected conditions %v", cr.Status.Conditions)
+	}
+
+	if err := r.client.Delete(ctx, restore); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcileDataSource(ctx, cr); err != nil {
+		t.Fatal(err)
+	}
+	if cr.Status.DataSource.State != api.RestoreStateNew || cr.Status.DataSource.Error != "" {
+		t.Errorf("data source status is not reset: %v", cr.Status.DataSource)
+	}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: "preview-data-source", Namespace: cr.Namespace}, restore); err != nil {
+		t.Errorf("restore is not restarted: %v", err)
+	}
+}
@@ -262,6 +262,11 @@ func (r *ReconcilePerconaServerMongoDB) deleteAllPVC(ctx context.Context, cr *ap
 	}
 
 	for _, pvc := range pvcList.Items {
+		// volumes of filesystem backup storages keep backups, they should outlive the cluster
+		if pvc.Labels[naming.LabelKubernetesComponent] == naming.ComponentBackupStorage {
+			continue
+		}
+
 		logf.FromContext(ctx).Info("deleting PVC", "name", pvc.Name)
 		err := r.client.Delete(ctx, &pvc)
 		if err != nil {
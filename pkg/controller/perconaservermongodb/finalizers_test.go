@@ -5,11 +5,14 @@ import (
 	"slices"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
 	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb"
 	"github.com/percona/percona-server-mongodb-operator/version"
 )
 
@@ -113,3 +116,61 @@ func TestCheckFinalizers(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteAllPVCKeepsBackupStorage(t *testing.T) {
+	ctx := context.Background()
+	crName := "delete-pvc"
+	ns := crName + "-ns"
+
+	cr, err := readDefaultCR(crName, ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr.Spec.Backup.Storages = map[string]api.BackupStorageSpec{
+		"fs": {
+			Type: api.BackupStorageFilesystem,
+			Filesystem: api.BackupStorageFilesystemSpec{
+				Path: "/mnt/backups",
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+					},
+				},
+			},
+		},
+	}
+	if err := cr.CheckNSetDefaults(version.PlatformKubernetes, logf.FromContext(ctx)); err != nil {
+		t.Fatal(err)
+	}
+
+	dataPVC := &corev1.PersistentVolumeClaim{}
+	dataPVC.Name = "mongod-data-" + crName + "-rs0-0"
+	dataPVC.Namespace = ns
+	dataPVC.Labels = naming.MongodLabels(cr, cr.Spec.Replsets[0])
+
+	r := buildFakeClient(cr, dataPVC)
+
+	if err := ensurePVCs(ctx, r.client, ns, psmdb.BackupStoragePVCs(cr)); err != nil {
+		t.Fatal(err)
+	}
+
+	stgPVC := &corev1.PersistentVolumeClaim{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: psmdb.BackupStoragePVCName(cr, "fs"), Namespace: ns}, stgPVC); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(stgPVC.Spec.AccessModes, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}) {
+		t.Fatal("unexpected access modes:", stgPVC.Spec.AccessModes)
+	}
+
+	if err := r.deleteAllPVC(ctx, cr); err != nil {
+		t.Fatal(err)
+	}
+
+	pvcs, err := r.getAllPVCs(ctx, cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pvcs.Items) != 1 || pvcs.Items[0].Name != stgPVC.Name {
+		t.Fatal("only the backup storage PVC is expected to be kept, got:", pvcs.Items)
+	}
+}
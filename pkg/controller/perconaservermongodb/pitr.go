@@ -0,0 +1,125 @@
+package perconaservermongodb
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/oplog"
+	"github.com/percona/percona-backup-mongodb/pbm/util"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+)
+
+// pitrRetentionInterval is how often the operator deletes oplog chunks
+// outside of spec.backup.pitr.retention.
+const pitrRetentionInterval = 10 * time.Minute
+
+// updatePITRWindow deletes oplog chunks outside of the PITR retention window
+// and reports the earliest and latest restorable time in the cluster status.
+func (r *ReconcilePerconaServerMongoDB) updatePITRWindow(ctx context.Context, pbm backup.PBM, cr *api.PerconaServerMongoDB) error {
+	log := logf.FromContext(ctx)
+
+	metas, err := pbm.GetDoneBackups(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get backups from pbm")
+	}
+	bases := pitrBaseBackups(metas)
+
+	if cr.Status.PITR == nil {
+		cr.Status.PITR = new(api.PITRStatus)
+	}
+	status := cr.Status.PITR
+
+	retention := cr.Spec.Backup.PITR.Retention
+	if retention != nil && retention.Duration > 0 {
+		now := time.Now()
+		if status.LastRetentionTime == nil || now.Sub(status.LastRetentionTime.Time) >= pitrRetentionInterval {
+			ts := pitrRetentionPoint(now.Add(-retention.Duration), bases)
+			if err := pbm.DeletePITRChunksBefore(ctx, ts); err != nil {
+				return errors.Wrap(err, "delete oplog chunks outside of retention")
+			}
+			log.V(1).Info("Oplog chunks outside of PITR retention are deleted", "before", time.Unix(int64(ts.T), 0).UTC())
+
+			status.LastRetentionTime = &metav1.Time{Time: now}
+		}
+	}
+
+	tl, err := pbm.GetLatestTimelinePITR(ctx)
+	if err != nil {
+		if err == backup.ErrNoOplogsForPITR {
+			status.EarliestRestorableTime = nil
+			status.LatestRestorableTime = nil
+			return nil
+		}
+		return errors.Wrap(err, "get latest PITR timeline")
+	}
+
+	status.LatestRestorableTime = &metav1.Time{Time: time.Unix(int64(tl.End), 0)}
+	status.EarliestRestorableTime = nil
+	if earliest, ok := earliestRestorableTime(tl, bases); ok {
+		status.EarliestRestorableTime = &metav1.Time{Time: earliest}
+	}
+
+	return nil
+}
+
+// pitrBaseBackups returns full backups in the main storage that can be a base
+// for point-in-time recovery, sorted by their last write time.
+func pitrBaseBackups(metas []backup.BackupMeta) []backup.BackupMeta {
+	bases := make([]backup.BackupMeta, 0, len(metas))
+	for _, meta := range metas {
+		// oplog chunks are stored only in the main storage
+		if meta.Store.IsProfile || meta.LastWriteTS.IsZero() {
+			continue
+		}
+		// selective backups can't be a base for point-in-time recovery
+		if util.IsSelective(meta.Namespaces) {
+			continue
+		}
+
+		switch meta.Type {
+		case defs.LogicalBackup, defs.PhysicalBackup, defs.IncrementalBackup:
+			bases = append(bases, meta)
+		}
+	}
+
+	sort.Slice(bases, func(i, j int) bool {
+		return bases[i].LastWriteTS.Before(bases[j].LastWriteTS)
+	})
+
+	return bases
+}
+
+// pitrRetentionPoint returns the timestamp oplog chunks that end before can be deleted.
+// Chunks after the newest base backup made at or before the cutoff are kept even if
+// they are older than the cutoff, otherwise the cutoff couldn't be restored from this backup.
+func pitrRetentionPoint(cutoff time.Time, bases []backup.BackupMeta) primitive.Timestamp {
+	ts := primitive.Timestamp{T: uint32(cutoff.Unix())}
+	for i := len(bases) - 1; i >= 0; i-- {
+		if !ts.Before(bases[i].LastWriteTS) {
+			return bases[i].LastWriteTS
+		}
+	}
+
+	return ts
+}
+
+// earliestRestorableTime returns the last write time of the oldest base backup
+// the timeline can be replayed from.
+func earliestRestorableTime(tl oplog.Timeline, bases []backup.BackupMeta) (time.Time, bool) {
+	for _, b := range bases {
+		if b.LastWriteTS.T >= tl.Start && b.LastWriteTS.T <= tl.End {
+			return time.Unix(int64(b.LastWriteTS.T), 0), true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,118 @@
+package perconaservermongodb
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	pbmBackup "github.com/percona/percona-backup-mongodb/pbm/backup"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/oplog"
+
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+)
+
+func TestPITRRetentionPoint(t *testing.T) {
+	meta := func(name string, bcpType defs.BackupType, lastWrite uint32, profile bool, namespaces ...string) backup.BackupMeta {
+		return backup.BackupMeta{
+			Name:        name,
+			Type:        bcpType,
+			LastWriteTS: primitive.Timestamp{T: lastWrite},
+			Store:       pbmBackup.Storage{IsProfile: profile},
+			Namespaces:  namespaces,
+		}
+	}
+
+	metas := []backup.BackupMeta{
+		meta("profile", defs.LogicalBackup, 100, true),
+		meta("external", defs.ExternalBackup, 150, false),
+		meta("selective", defs.LogicalBackup, 250, false, "app.*"),
+		meta("newest", defs.PhysicalBackup, 500, false),
+		meta("oldest", defs.LogicalBackup, 300, false),
+	}
+
+	bases := pitrBaseBackups(metas)
+	if len(bases) != 2 || bases[0].Name != "oldest" || bases[1].Name != "newest" {
+		t.Fatalf("unexpected base backups: %v", bases)
+	}
+
+	tests := map[string]struct {
+		cutoff   int64
+		bases    []backup.BackupMeta
+		expected uint32
+	}{
+		"no backups": {
+			cutoff:   400,
+			expected: 400,
+		},
+		"cutoff before oldest backup": {
+			cutoff:   200,
+			bases:    bases,
+			expected: 200,
+		},
+		"cutoff after oldest backup": {
+			cutoff:   400,
+			bases:    bases,
+			expected: 300,
+		},
+		"cutoff at newest backup": {
+			cutoff:   500,
+			bases:    bases,
+			expected: 500,
+		},
+		"cutoff after newest backup": {
+			cutoff:   600,
+			bases:    bases,
+			expected: 500,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := pitrRetentionPoint(time.Unix(tt.cutoff, 0), tt.bases)
+			if ts.T != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, ts.T)
+			}
+		})
+	}
+}
+
+func TestEarliestRestorableTime(t *testing.T) {
+	bases := []backup.BackupMeta{
+		{Name: "first", LastWriteTS: primitive.Timestamp{T: 100}},
+		{Name: "second", LastWriteTS: primitive.Timestamp{T: 300}},
+	}
+
+	tests := map[string]struct {
+		tl       oplog.Timeline
+		expected int64
+		ok       bool
+	}{
+		"timeline from the first backup": {
+			tl:       oplog.Timeline{Start: 90, End: 500},
+			expected: 100,
+			ok:       true,
+		},
+		"gap after the first backup": {
+			tl:       oplog.Timeline{Start: 200, End: 500},
+			expected: 300,
+			ok:       true,
+		},
+		"no backups in timeline": {
+			tl: oplog.Timeline{Start: 400, End: 500},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			earliest, ok := earliestRestorableTime(tt.tl, bases)
+			if ok != tt.ok {
+				t.Fatalf("expected ok %t, got %t", tt.ok, ok)
+			}
+			if ok && earliest.Unix() != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, earliest.Unix())
+			}
+		})
+	}
+}
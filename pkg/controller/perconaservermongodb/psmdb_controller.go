@@ -38,6 +38,7 @@ import (
 	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/metrics"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/secret"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/tls"
 	"github.com/percona/percona-server-mongodb-operator/pkg/util"
@@ -248,6 +249,7 @@ func (r *ReconcilePerconaServerMongoDB) Reconcile(ctx context.Context, request r
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			metrics.DeleteCluster(request.Namespace, request.Name)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -296,6 +298,8 @@ func (r *ReconcilePerconaServerMongoDB) Reconcile(ctx context.Context, request r
 	}
 
 	if cr.ObjectMeta.DeletionTimestamp != nil {
+		metrics.DeleteCluster(cr.Namespace, cr.Name)
+
 		rec, err := r.checkFinalizers(ctx, cr)
 		if rec || err != nil {
 			return rr, err
@@ -408,6 +412,10 @@ func (r *ReconcilePerconaServerMongoDB) Reconcile(ctx context.Context, request r
 			err = errors.Wrap(err, "reconcile backup tasks")
 			return reconcile.Result{}, err
 		}
+
+		if err := ensurePVCs(ctx, r.client, cr.Namespace, psmdb.BackupStoragePVCs(cr)); err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "ensure backup storage PVCs")
+		}
 	}
 
 	clusterStatus, err = r.reconcileReplsets(ctx, cr, repls)
@@ -447,6 +455,10 @@ func (r *ReconcilePerconaServerMongoDB) Reconcile(ctx context.Context, request r
 		return reconcile.Result{}, errors.Wrap(err, "failed to ensure version")
 	}
 
+	if err = r.reconcileDataSource(ctx, cr); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "reconcile data source")
+	}
+
 	if err = r.updatePITR(ctx, cr); err != nil {
 		return rr, err
 	}
@@ -456,6 +468,11 @@ func (r *ReconcilePerconaServerMongoDB) Reconcile(ctx context.Context, request r
 		return reconcile.Result{}, errors.Wrap(err, "resync PBM if needed")
 	}
 
+	err = r.importBackupsIfNeeded(ctx, cr)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "import backups")
+	}
+
 	return rr, nil
 }
 
@@ -216,6 +216,11 @@ func (r *ReconcilePerconaServerMongoDB) updateStatus(ctx context.Context, cr *ap
 		}
 	}
 
+	if state == api.AppStateReady && cr.DataSourcePending() {
+		log.V(1).Info("Waiting for data source to be restored")
+		state = api.AppStateInit
+	}
+
 	if state != api.AppStateReady {
 		log.V(1).Info("Cluster is not ready",
 			"upgradeInProgress", inProgress,
@@ -283,7 +283,7 @@ func (r *ReconcilePerconaServerMongoDB) getVersionMeta(ctx context.Context, cr *
 	}
 
 	for _, task := range cr.Spec.Backup.Tasks {
-		if task.Type == defs.PhysicalBackup && task.Enabled {
+		if (task.Type == defs.PhysicalBackup || task.Type == defs.IncrementalBackup) && task.Enabled {
 			vm.PhysicalBackupScheduled = true
 			break
 		}
@@ -3,7 +3,6 @@ package perconaservermongodbbackup
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -15,6 +14,8 @@ import (
 	"github.com/percona/percona-backup-mongodb/pbm/ctrl"
 	"github.com/percona/percona-backup-mongodb/pbm/defs"
 	pbmErrors "github.com/percona/percona-backup-mongodb/pbm/errors"
+	"github.com/percona/percona-backup-mongodb/pbm/storage"
+	"github.com/percona/percona-backup-mongodb/pbm/util"
 	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
 )
@@ -23,6 +24,12 @@ const (
 	// pbmStartingDeadline is timeout after which continuous starting state is considered as error
 	pbmStartingDeadline       = time.Duration(120) * time.Second
 	pbmStartingDeadlineErrMsg = "starting deadline exceeded"
+
+	// pbmCancelDeadline is the time after the running timeout of a backup is exceeded
+	// after which the backup is failed and its locks are released if PBM didn't cancel it
+	pbmCancelDeadline = time.Duration(120) * time.Second
+
+	canceledByUserErrMsg = "backup is canceled by user"
 )
 
 type Backup struct {
@@ -45,7 +52,7 @@ func (r *ReconcilePerconaServerMongoDBBackup) newBackup(ctx context.Context, clu
 // Start requests backup on PBM
 func (b *Backup) Start(ctx context.Context, k8sclient client.Client, cluster *api.PerconaServerMongoDB, cr *api.PerconaServerMongoDBBackup) (api.PerconaServerMongoDBBackupStatus, error) {
 	log := logf.FromContext(ctx)
-	log.Info("Starting backup", "backup", cr.Name, "storage", cr.Spec.StorageName)
+	log.Info("Starting backup", "backup", cr.Name, "storage", cr.Spec.StorageName, "type", cr.Spec.Type)
 
 	var status api.PerconaServerMongoDBBackupStatus
 
@@ -54,9 +61,19 @@ func (b *Backup) Start(ctx context.Context, k8sclient client.Client, cluster *ap
 		return status, errors.Errorf("unable to get storage '%s'", cr.Spec.StorageName)
 	}
 
-	err := b.pbm.GetNSetConfig(ctx, k8sclient, cluster, stg)
-	if err != nil {
-		return api.PerconaServerMongoDBBackupStatus{}, errors.Wrapf(err, "set backup config with storage %s", cr.Spec.StorageName)
+	profile := b.spec.StorageProfile(cr.Spec.StorageName)
+	if profile != "" {
+		// main storage keeps PITR oplog chunks, so backups to other storages
+		// use config profiles instead of switching PBM main storage
+		err := b.pbm.GetNSetProfile(ctx, k8sclient, cluster, profile, stg)
+		if err != nil {
+			return api.PerconaServerMongoDBBackupStatus{}, errors.Wrapf(err, "set backup config profile with storage %s", cr.Spec.StorageName)
+		}
+	} else {
+		err := b.pbm.GetNSetConfig(ctx, k8sclient, cluster, stg)
+		if err != nil {
+			return api.PerconaServerMongoDBBackupStatus{}, errors.Wrapf(err, "set backup config with storage %s", cr.Spec.StorageName)
+		}
 	}
 
 	name := time.Now().UTC().Format(time.RFC3339)
@@ -72,12 +89,15 @@ func (b *Backup) Start(ctx context.Context, k8sclient client.Client, cluster *ap
 		Backup: &ctrl.BackupCmd{
 			Name:             name,
 			Type:             cr.Spec.Type,
+			IncrBase:         cr.Spec.IncrementalBase,
+			Namespaces:       cr.Spec.Namespaces,
 			Compression:      cr.Spec.Compression,
 			CompressionLevel: compLevel,
+			Profile:          profile,
 		},
 	}
 	log.Info("Sending backup command", "backupCmd", cmd)
-	err = b.pbm.SendCmd(ctx, cmd)
+	err := b.pbm.SendCmd(ctx, cmd)
 	if err != nil {
 		return status, err
 	}
@@ -88,7 +108,8 @@ func (b *Backup) Start(ctx context.Context, k8sclient client.Client, cluster *ap
 		LastTransition: &metav1.Time{
 			Time: time.Unix(time.Now().Unix(), 0),
 		},
-		State: api.BackupStateRequested,
+		State:      api.BackupStateRequested,
+		Namespaces: cr.Spec.Namespaces,
 	}
 	if cluster.Spec.Sharding.Enabled && cluster.Spec.Sharding.ConfigsvrReplSet != nil {
 		status.ReplsetNames = append(status.ReplsetNames, cluster.Spec.Sharding.ConfigsvrReplSet.Name)
@@ -100,35 +121,12 @@ func (b *Backup) Start(ctx context.Context, k8sclient client.Client, cluster *ap
 	switch stg.Type {
 	case api.BackupStorageS3:
 		status.S3 = &stg.S3
-
-		status.Destination = stg.S3.Bucket
-
-		if stg.S3.Prefix != "" {
-			status.Destination = stg.S3.Bucket + "/" + stg.S3.Prefix
-		}
-		if !strings.HasPrefix(stg.S3.Bucket, "s3://") {
-			status.Destination = "s3://" + status.Destination
-		}
 	case api.BackupStorageAzure:
 		status.Azure = &stg.Azure
-
-		status.Destination = stg.Azure.Container
-
-		if stg.Azure.Prefix != "" {
-			status.Destination = stg.Azure.Container + "/" + stg.Azure.Prefix
-		}
-		if !strings.HasPrefix(stg.Azure.Container, "azure://") {
-			if stg.Azure.EndpointURL != "" {
-				status.Destination = stg.Azure.EndpointURL + "/" + status.Destination
-			} else {
-				status.Destination = "azure://" + status.Destination
-			}
-		}
 	case api.BackupStorageFilesystem:
 		status.Filesystem = &stg.Filesystem
-		status.Destination = strings.TrimSuffix(stg.Filesystem.Path, "/")
 	}
-	status.Destination += "/" + status.PBMname
+	status.Destination = backup.Destination(stg, status.PBMname)
 
 	return status, nil
 }
@@ -162,6 +160,16 @@ func (b *Backup) Status(ctx context.Context, cr *api.PerconaServerMongoDBBackup)
 		status.CompletedAt = &metav1.Time{
 			Time: time.Unix(meta.LastTransitionTS, 0),
 		}
+		if meta.StartTS > 0 {
+			status.Duration = &metav1.Duration{
+				Duration: time.Duration(meta.LastTransitionTS-meta.StartTS) * time.Second,
+			}
+		}
+	case defs.StatusCancelled:
+		status.State = api.BackupStateError
+		if status.Error == "" {
+			status.Error = "backup is canceled"
+		}
 	case defs.StatusStarting:
 		passed := time.Now().UTC().Sub(time.Unix(meta.StartTS, 0))
 		if passed >= pbmStartingDeadline {
@@ -173,12 +181,43 @@ func (b *Backup) Status(ctx context.Context, cr *api.PerconaServerMongoDBBackup)
 		status.State = api.BackupStateRequested
 	default:
 		status.State = api.BackupStateRunning
+
+		_, canceled := cr.Annotations[api.AnnotationCancelBackup]
+		reason, release := cancelReason(b.spec.Configuration.BackupOptions, meta, canceled, time.Now().UTC())
+		switch {
+		case reason == "":
+		case !release:
+			// the backup stays running until PBM agents cancel it and release the lock,
+			// the error is set to not send the cancel command again
+			if status.Error != reason {
+				if err := b.Cancel(ctx, reason); err != nil {
+					return status, errors.Wrap(err, "cancel backup")
+				}
+			}
+			status.Error = reason
+		default:
+			if err := b.Cancel(ctx, reason); err != nil {
+				return status, errors.Wrap(err, "cancel backup")
+			}
+			if err := b.pbm.ReleaseLocks(ctx, meta.OPID); err != nil {
+				return status, errors.Wrap(err, "release backup locks")
+			}
+			status.State = api.BackupStateError
+			status.Error = reason
+		}
 	}
 
 	status.LastTransition = &metav1.Time{
 		Time: time.Unix(meta.LastTransitionTS, 0),
 	}
 	status.Type = cr.Spec.Type
+	status.SrcBackup = meta.SrcBackup
+	status.MongoVersion = meta.MongoVersion
+	status.FCV = meta.FCV
+	if util.IsSelective(meta.Namespaces) {
+		status.Namespaces = meta.Namespaces
+	}
+	setBackupSizes(&status, meta)
 
 	node, err := b.pbm.Node(ctx)
 	if err != nil {
@@ -196,6 +235,47 @@ func (b *Backup) Status(ctx context.Context, cr *api.PerconaServerMongoDBBackup)
 	return status, nil
 }
 
+// cancelReason returns the reason to cancel a running backup or an empty string
+// if the backup is neither canceled by user, running longer than the running timeout nor stalled.
+// It returns true if the locks of the backup should be released by the operator:
+// the backup is stalled, PBM didn't cancel it in pbmCancelDeadline after the running timeout,
+// or the backup canceled by user has no heartbeat from PBM for pbmCancelDeadline.
+func cancelReason(opts *api.BackupOptions, meta *pbmBackup.BackupMeta, canceled bool, now time.Time) (string, bool) {
+	if canceled {
+		last := time.Unix(meta.StartTS, 0)
+		if meta.Hb.T > 0 {
+			last = time.Unix(int64(meta.Hb.T), 0)
+		}
+		return canceledByUserErrMsg, now.Sub(last) >= pbmCancelDeadline
+	}
+
+	if opts == nil || opts.Timeouts == nil {
+		return "", false
+	}
+
+	if t := opts.Timeouts.Running; t != nil && *t > 0 && meta.StartTS > 0 {
+		timeout := time.Duration(*t) * time.Second
+		if passed := now.Sub(time.Unix(meta.StartTS, 0)); passed >= timeout {
+			return fmt.Sprintf("running deadline exceeded: backup is running longer than %s", timeout), passed >= timeout+pbmCancelDeadline
+		}
+	}
+
+	if t := opts.Timeouts.Heartbeat; t != nil && *t > 0 && meta.Hb.T > 0 {
+		if passed := now.Sub(time.Unix(int64(meta.Hb.T), 0)); passed >= time.Duration(*t)*time.Second {
+			return fmt.Sprintf("backup is stalled: no heartbeat from PBM for %s", passed.Truncate(time.Second)), true
+		}
+	}
+
+	return "", false
+}
+
+// Cancel sends the cancel-backup command to PBM.
+func (b *Backup) Cancel(ctx context.Context, reason string) error {
+	logf.FromContext(ctx).Info("Canceling backup", "reason", reason)
+
+	return errors.Wrap(b.pbm.SendCmd(ctx, ctrl.Cmd{Cmd: ctrl.CmdCancelBackup}), "send cancel-backup command")
+}
+
 func backupPods(replsets []pbmBackup.BackupReplset) map[string]string {
 	pods := make(map[string]string)
 	for _, rs := range replsets {
@@ -204,6 +284,59 @@ func backupPods(replsets []pbmBackup.BackupReplset) map[string]string {
 	return pods
 }
 
+// setBackupSizes fills the size related fields of the status from the backup metadata.
+// PBM stores the list of files only for physical and incremental backups,
+// so per-replset sizes and the compression ratio can't be calculated for logical backups.
+// Since PBM 2.4.1 the list of files is kept in the storage instead of the metadata.
+func setBackupSizes(status *api.PerconaServerMongoDBBackupStatus, meta *pbmBackup.BackupMeta) {
+	if meta.Size > 0 {
+		status.SizeBytes = meta.Size
+		status.Size = storage.PrettySize(meta.Size)
+	}
+
+	if meta.Type == defs.LogicalBackup {
+		if len(meta.Replsets) == 1 && meta.Size > 0 {
+			status.ReplsetSizes = map[string]int64{meta.Replsets[0].Name: meta.Size}
+		}
+		return
+	}
+
+	files := make(map[string][]pbmBackup.File)
+	for _, rs := range meta.Replsets {
+		if len(rs.Files) == 0 {
+			return
+		}
+		files[rs.Name] = rs.Files
+	}
+	setFilesStats(status, files)
+}
+
+// setFilesStats sets per-replset sizes and the compression ratio of physical backup files.
+func setFilesStats(status *api.PerconaServerMongoDBBackupStatus, files map[string][]pbmBackup.File) {
+	var stored, uncompressed int64
+	sizes := make(map[string]int64)
+	for rs, rsFiles := range files {
+		var rsSize int64
+		for _, f := range rsFiles {
+			rsSize += f.StgSize
+			if f.Len > 0 {
+				uncompressed += f.Len
+			} else {
+				uncompressed += f.Size
+			}
+		}
+		sizes[rs] = rsSize
+		stored += rsSize
+	}
+
+	if stored == 0 {
+		return
+	}
+
+	status.ReplsetSizes = sizes
+	status.CompressionRatio = fmt.Sprintf("%.2f", float64(uncompressed)/float64(stored))
+}
+
 // Close closes the PBM connection
 func (b *Backup) Close(ctx context.Context) error {
 	if b.pbm == nil {
@@ -0,0 +1,194 @@
+package perconaservermongodbbackup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	pbmBackup "github.com/percona/percona-backup-mongodb/pbm/backup"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+)
+
+func TestSetBackupSizes(t *testing.T) {
+	tests := map[string]struct {
+		meta             *pbmBackup.BackupMeta
+		size             string
+		replsetSizes     map[string]int64
+		compressionRatio string
+	}{
+		"logical single replset": {
+			meta: &pbmBackup.BackupMeta{
+				Type:     defs.LogicalBackup,
+				Size:     2048,
+				Replsets: []pbmBackup.BackupReplset{{Name: "rs0"}},
+			},
+			size:         "2.00KB",
+			replsetSizes: map[string]int64{"rs0": 2048},
+		},
+		"logical sharded": {
+			meta: &pbmBackup.BackupMeta{
+				Type:     defs.LogicalBackup,
+				Size:     2048,
+				Replsets: []pbmBackup.BackupReplset{{Name: "rs0"}, {Name: "cfg"}},
+			},
+			size: "2.00KB",
+		},
+		"physical with files in metadata": {
+			meta: &pbmBackup.BackupMeta{
+				Type: defs.PhysicalBackup,
+				Size: 300,
+				Replsets: []pbmBackup.BackupReplset{
+					{Name: "rs0", Files: []pbmBackup.File{{Size: 400, StgSize: 100}, {Size: 200, StgSize: 100}}},
+					{Name: "cfg", Files: []pbmBackup.File{{Size: 1000, Off: 600, Len: 300, StgSize: 100}}},
+				},
+			},
+			size:             "300.00B",
+			replsetSizes:     map[string]int64{"rs0": 200, "cfg": 100},
+			compressionRatio: "3.00",
+		},
+		"physical with files in storage": {
+			meta: &pbmBackup.BackupMeta{
+				Type:     defs.PhysicalBackup,
+				Size:     300,
+				Replsets: []pbmBackup.BackupReplset{{Name: "rs0"}},
+			},
+			size: "300.00B",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			status := api.PerconaServerMongoDBBackupStatus{}
+			setBackupSizes(&status, tt.meta)
+
+			if status.Size != tt.size {
+				t.Errorf("expected size %s, got %s", tt.size, status.Size)
+			}
+			if status.SizeBytes != tt.meta.Size {
+				t.Errorf("expected size bytes %d, got %d", tt.meta.Size, status.SizeBytes)
+			}
+			if !reflect.DeepEqual(status.ReplsetSizes, tt.replsetSizes) {
+				t.Errorf("expected replset sizes %v, got %v", tt.replsetSizes, status.ReplsetSizes)
+			}
+			if status.CompressionRatio != tt.compressionRatio {
+				t.Errorf("expected compression ratio %s, got %s", tt.compressionRatio, status.CompressionRatio)
+			}
+		})
+	}
+}
+
+func TestCancelReason(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	ptr := func(v uint32) *uint32 { return &v }
+
+	meta := func(started, hb time.Duration) *pbmBackup.BackupMeta {
+		return &pbmBackup.BackupMeta{
+			StartTS: now.Add(-started).Unix(),
+			Hb:      primitive.Timestamp{T: uint32(now.Add(-hb).Unix())},
+		}
+	}
+
+	tests := map[string]struct {
+		opts     *api.BackupOptions
+		meta     *pbmBackup.BackupMeta
+		canceled bool
+		expected bool
+		release  bool
+	}{
+		"no options": {
+			meta: meta(time.Hour, 10*time.Minute),
+		},
+		"no heartbeat yet": {
+			opts: &api.BackupOptions{Timeouts: &api.BackupTimeouts{Heartbeat: ptr(300)}},
+			meta: &pbmBackup.BackupMeta{StartTS: now.Add(-time.Hour).Unix()},
+		},
+		"stalled": {
+			opts:     &api.BackupOptions{Timeouts: &api.BackupTimeouts{Heartbeat: ptr(300)}},
+			meta:     meta(time.Hour, 10*time.Minute),
+			expected: true,
+			release:  true,
+		},
+		"heartbeat deadline not exceeded": {
+			opts: &api.BackupOptions{Timeouts: &api.BackupTimeouts{Heartbeat: ptr(900)}},
+			meta: meta(time.Hour, 10*time.Minute),
+		},
+		"running deadline exceeded": {
+			opts:     &api.BackupOptions{Timeouts: &api.BackupTimeouts{Running: ptr(3590)}},
+			meta:     meta(time.Hour, 10*time.Second),
+			expected: true,
+		},
+		"running deadline exceeded and not canceled": {
+			opts:     &api.BackupOptions{Timeouts: &api.BackupTimeouts{Running: ptr(1800)}},
+			meta:     meta(time.Hour, 10*time.Second),
+			expected: true,
+			release:  true,
+		},
+		"running deadline not exceeded": {
+			opts: &api.BackupOptions{Timeouts: &api.BackupTimeouts{Running: ptr(7200)}},
+			meta: meta(time.Hour, 10*time.Second),
+		},
+		"canceled by user": {
+			meta:     meta(time.Hour, 10*time.Second),
+			canceled: true,
+			expected: true,
+		},
+		"canceled by user and not canceled by PBM": {
+			meta:     meta(time.Hour, 10*time.Minute),
+			canceled: true,
+			expected: true,
+			release:  true,
+		},
+		"canceled by user without heartbeat": {
+			meta:     &pbmBackup.BackupMeta{StartTS: now.Add(-time.Hour).Unix()},
+			canceled: true,
+			expected: true,
+			release:  true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			reason, release := cancelReason(tt.opts, tt.meta, tt.canceled, now)
+			if (reason != "") != tt.expected {
+				t.Errorf("unexpected cancel reason: %q", reason)
+			}
+			if release != tt.release {
+				t.Errorf("expected release %t, got %t", tt.release, release)
+			}
+		})
+	}
+}
+
+func TestChainIncrements(t *testing.T) {
+	bcp := func(name, cluster, pbmName, src string) api.PerconaServerMongoDBBackup {
+		b := api.PerconaServerMongoDBBackup{}
+		b.Name = name
+		b.Spec.ClusterName = cluster
+		b.Status.Type = defs.IncrementalBackup
+		b.Status.PBMname = pbmName
+		b.Status.SrcBackup = src
+		return b
+	}
+
+	base := bcp("base", "cluster", "b0", "")
+	bcps := []api.PerconaServerMongoDBBackup{
+		bcp("inc2", "cluster", "b2", "b1"),
+		base,
+		bcp("inc1", "cluster", "b1", "b0"),
+		bcp("other-base", "cluster", "c0", ""),
+		bcp("other-inc", "cluster", "c1", "c0"),
+		bcp("other-cluster", "other", "d1", "b0"),
+	}
+
+	var got []string
+	for _, b := range chainIncrements(&base, bcps) {
+		got = append(got, b.Name)
+	}
+	if expected := []string{"inc1", "inc2"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
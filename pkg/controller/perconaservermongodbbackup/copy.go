@@ -0,0 +1,365 @@
+package perconaservermongodbbackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	pbmBackup "github.com/percona/percona-backup-mongodb/pbm/backup"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/storage"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+)
+
+// copyTimeout is the time after which a copy that keeps failing is marked as failed.
+const copyTimeout = 24 * time.Hour
+
+// copyJob is a copy of the backup to a storage that runs in the background.
+type copyJob struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	err    error
+}
+
+// copyJobsPrefix returns the prefix of the keys of the backup copy jobs in copyJobs.
+// Without uid it matches the jobs of all backups with the given name.
+func copyJobsPrefix(namespace, name string, uid types.UID) string {
+	return namespace + "/" + name + "/" + string(uid)
+}
+
+// cancelCopyJobs cancels the copy jobs with the given key prefix and removes them from copyJobs.
+func cancelCopyJobs(copyJobs *sync.Map, prefix string) {
+	if copyJobs == nil {
+		return
+	}
+	copyJobs.Range(func(k, v any) bool {
+		if strings.HasPrefix(k.(string), prefix) {
+			if job := v.(*copyJob); job.cancel != nil {
+				job.cancel()
+			}
+			copyJobs.Delete(k)
+		}
+		return true
+	})
+}
+
+// reconcileCopies copies the backup to the storages listed in spec.copyTo one by one.
+// It returns the updated list of copy statuses. A copy is marked as running first
+// and started in the background on the next reconcile, its result is picked up by later reconciles.
+// Copying is retried until copyTimeout, files that are already in the target storage are not copied again.
+func (r *ReconcilePerconaServerMongoDBBackup) reconcileCopies(
+	ctx context.Context,
+	cluster *psmdbv1.PerconaServerMongoDB,
+	cr *psmdbv1.PerconaServerMongoDBBackup,
+) ([]psmdbv1.BackupCopyStatus, error) {
+	log := logf.FromContext(ctx)
+
+	bcp := cr.DeepCopy()
+	now := metav1.Now()
+
+	for _, stgName := range bcp.Spec.CopyTo {
+		c := bcp.CopyStatus(stgName)
+		if c != nil && (c.State == psmdbv1.BackupStateReady || c.State == psmdbv1.BackupStateError) {
+			continue
+		}
+
+		if c == nil {
+			bcp.Status.Copies = append(bcp.Status.Copies, psmdbv1.BackupCopyStatus{
+				StorageName: stgName,
+				State:       psmdbv1.BackupStateRunning,
+				StartAt:     &now,
+			})
+			c = &bcp.Status.Copies[len(bcp.Status.Copies)-1]
+
+			stg, err := copyStorage(cluster, bcp, stgName)
+			if err != nil {
+				c.State = psmdbv1.BackupStateError
+				c.Error = err.Error()
+				c.CompletedAt = &now
+				return bcp.Status.Copies, nil
+			}
+			setCopyStorage(c, stg, bcp.Status.PBMname)
+
+			log.Info("Copying backup", "backup", cr.Name, "storage", stgName)
+			return bcp.Status.Copies, nil
+		}
+
+		stg, err := copyStorage(cluster, bcp, stgName)
+		if err == nil {
+			var done bool
+			done, err = r.runCopyJob(ctx, cluster, bcp, stgName, stg)
+			if err == nil && !done {
+				return bcp.Status.Copies, nil
+			}
+		}
+		if err != nil {
+			c.Error = err.Error()
+			if c.StartAt != nil && time.Since(c.StartAt.Time) < copyTimeout {
+				return bcp.Status.Copies, errors.Wrapf(err, "copy to storage %s", stgName)
+			}
+			c.State = psmdbv1.BackupStateError
+			c.CompletedAt = &now
+			return bcp.Status.Copies, errors.Wrapf(err, "copy to storage %s", stgName)
+		}
+
+		c.State = psmdbv1.BackupStateReady
+		c.Error = ""
+		c.CompletedAt = &now
+		log.Info("Backup is copied", "backup", cr.Name, "storage", stgName, "destination", c.Destination)
+
+		return bcp.Status.Copies, nil
+	}
+
+	return bcp.Status.Copies, nil
+}
+
+// runCopyJob starts copying the backup to the storage in the background if it's not started yet.
+// It returns true and the copy error once the copy is finished.
+func (r *ReconcilePerconaServerMongoDBBackup) runCopyJob(
+	ctx context.Context,
+	cluster *psmdbv1.PerconaServerMongoDB,
+	cr *psmdbv1.PerconaServerMongoDBBackup,
+	stgName string,
+	stg psmdbv1.BackupStorageSpec,
+) (bool, error) {
+	key := copyJobsPrefix(cr.Namespace, cr.Name, cr.UID) + "/" + stgName
+
+	v, ok := r.copyJobs.Load(key)
+	if !ok {
+		// The reconcile context is cancelled when the manager stops,
+		// so the job is cancelled on shutdown as well.
+		jobCtx, cancel := context.WithCancel(ctx)
+		job := &copyJob{done: make(chan struct{}), cancel: cancel}
+		r.copyJobs.Store(key, job)
+
+		cluster = cluster.DeepCopy()
+		cr = cr.DeepCopy()
+		go func() {
+			defer cancel()
+			defer close(job.done)
+			job.err = r.copyBackup(jobCtx, cluster, cr, stgName, stg)
+		}()
+
+		return false, nil
+	}
+
+	job := v.(*copyJob)
+	select {
+	case <-job.done:
+		r.copyJobs.Delete(key)
+		return true, job.err
+	default:
+		return false, nil
+	}
+}
+
+func copyStorage(cluster *psmdbv1.PerconaServerMongoDB, cr *psmdbv1.PerconaServerMongoDBBackup, stgName string) (psmdbv1.BackupStorageSpec, error) {
+	if cluster == nil {
+		return psmdbv1.BackupStorageSpec{}, errors.New("cluster not found")
+	}
+
+	stg, ok := cluster.Spec.Backup.Storages[stgName]
+	if !ok {
+		return psmdbv1.BackupStorageSpec{}, errors.Errorf("storage %s doesn't exist", stgName)
+	}
+
+	if stg.Type == psmdbv1.BackupStorageFilesystem || cr.Status.Filesystem != nil {
+		return psmdbv1.BackupStorageSpec{}, errors.New("filesystem storages are not supported")
+	}
+
+	return stg, nil
+}
+
+func setCopyStorage(c *psmdbv1.BackupCopyStatus, stg psmdbv1.BackupStorageSpec, pbmName string) {
+	switch stg.Type {
+	case psmdbv1.BackupStorageS3:
+		c.S3 = &stg.S3
+	case psmdbv1.BackupStorageAzure:
+		c.Azure = &stg.Azure
+	}
+	c.Destination = backup.Destination(stg, pbmName)
+}
+
+// copyBackup streams backup files and PBM metadata to the target storage
+// and resyncs the target storage in PBM. Incremental backups are copied together
+// with the backups they are based on, since they can't be restored without them.
+func (r *ReconcilePerconaServerMongoDBBackup) copyBackup(
+	ctx context.Context,
+	cluster *psmdbv1.PerconaServerMongoDB,
+	cr *psmdbv1.PerconaServerMongoDBBackup,
+	stgName string,
+	stg psmdbv1.BackupStorageSpec,
+) error {
+	src, err := r.getPBMStorage(ctx, cluster, cr)
+	if err != nil {
+		return errors.Wrap(err, "get backup storage")
+	}
+
+	target := cr.DeepCopy()
+	target.Status = psmdbv1.PerconaServerMongoDBBackupStatus{
+		S3:    cr.CopyStatus(stgName).S3,
+		Azure: cr.CopyStatus(stgName).Azure,
+	}
+	dst, err := r.getPBMStorage(ctx, cluster, target)
+	if err != nil {
+		return errors.Wrap(err, "get target storage")
+	}
+
+	dstConf, err := backup.GetPBMConfig(ctx, r.client, cluster, stg)
+	if err != nil {
+		return errors.Wrap(err, "get target pbm config")
+	}
+	profile := cluster.Spec.Backup.StorageProfile(stgName)
+	store := pbmBackup.Storage{
+		Name:        profile,
+		IsProfile:   profile != "",
+		StorageConf: dstConf.Storage,
+	}
+
+	names := []string{cr.Status.PBMname}
+	if cr.Status.Type == defs.IncrementalBackup {
+		chain, err := backup.BackupChain(cr.Status.PBMname, func(name string) (*pbmBackup.BackupMeta, error) {
+			return pbmBackup.ReadMetadata(src, name+defs.MetadataFileSuffix)
+		})
+		if err != nil {
+			return errors.Wrap(err, "get incremental backup chain")
+		}
+
+		names = make([]string, 0, len(chain))
+		for _, meta := range chain {
+			names = append(names, meta.Name)
+		}
+	}
+
+	for _, name := range names {
+		if err := copyBackupFiles(ctx, src, dst, name, store); err != nil {
+			return errors.Wrapf(err, "copy backup %s", name)
+		}
+	}
+
+	return r.resyncCopyStorage(ctx, cluster, stgName, stg)
+}
+
+// copyBackupFiles copies the backup data files and the metadata file. The metadata
+// file is copied last so PBM doesn't see the backup in the target storage until all files are copied.
+// The storage in the copied metadata is replaced with the given target storage.
+func copyBackupFiles(ctx context.Context, src, dst storage.Storage, name string, store pbmBackup.Storage) error {
+	files, err := src.List(name+"/", "")
+	if err != nil {
+		return errors.Wrap(err, "list files")
+	}
+	if len(files) == 0 {
+		return errors.New("no backup files found")
+	}
+
+	for _, f := range files {
+		if err := copyFile(ctx, src, dst, path.Join(name, f.Name), f.Size); err != nil {
+			return err
+		}
+	}
+
+	metaName := name + defs.MetadataFileSuffix
+	meta, err := pbmBackup.ReadMetadata(src, metaName)
+	if err != nil {
+		return errors.Wrapf(err, "read %s", metaName)
+	}
+	meta.Store = store
+
+	b, err := json.MarshalIndent(meta, "", "\t")
+	if err != nil {
+		return errors.Wrapf(err, "marshal %s", metaName)
+	}
+
+	return errors.Wrapf(dst.Save(metaName, bytes.NewReader(b), int64(len(b))), "save %s", metaName)
+}
+
+func copyFile(ctx context.Context, src, dst storage.Storage, name string, size int64) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Wrapf(err, "copy %s", name)
+	}
+
+	if f, err := dst.FileStat(name); err == nil && f.Size == size {
+		return nil
+	}
+
+	rdr, err := src.SourceReader(name)
+	if err != nil {
+		return errors.Wrapf(err, "read %s", name)
+	}
+	defer rdr.Close()
+
+	if err := dst.Save(name, &ctxReader{ctx: ctx, r: rdr}, size); err != nil {
+		return errors.Wrapf(err, "save %s", name)
+	}
+
+	return nil
+}
+
+// ctxReader stops reading once the context is cancelled,
+// storages don't accept a context to interrupt saving a file.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// resyncCopyStorage makes the copy visible in PBM. Storages that are neither a profile
+// nor the current PBM storage are resynced when they are used for restore.
+func (r *ReconcilePerconaServerMongoDBBackup) resyncCopyStorage(
+	ctx context.Context,
+	cluster *psmdbv1.PerconaServerMongoDB,
+	stgName string,
+	stg psmdbv1.BackupStorageSpec,
+) error {
+	pbmc, err := r.newPBMFunc(ctx, r.client, cluster)
+	if err != nil {
+		return errors.Wrap(err, "create pbm object")
+	}
+	defer pbmc.Close(ctx)
+
+	if profile := cluster.Spec.Backup.StorageProfile(stgName); profile != "" {
+		if err := pbmc.GetNSetProfile(ctx, r.client, cluster, profile, stg); err != nil {
+			return errors.Wrapf(err, "set config profile %s", profile)
+		}
+		return errors.Wrapf(pbmc.ResyncProfile(ctx, profile), "resync config profile %s", profile)
+	}
+
+	cfg, err := pbmc.GetConfig(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get pbm config")
+	}
+
+	expected, err := backup.GetPBMConfig(ctx, r.client, cluster, stg)
+	if err != nil {
+		return errors.Wrap(err, "get expected pbm config")
+	}
+
+	// PBM fills defaults of the stored config
+	if err := expected.Storage.Cast(); err != nil {
+		return errors.Wrap(err, "cast expected storage config")
+	}
+
+	if !cfg.Storage.Equal(&expected.Storage) {
+		return nil
+	}
+
+	return errors.Wrapf(pbmc.ResyncStorage(ctx, &cfg.Storage), "resync storage %s", stgName)
+}
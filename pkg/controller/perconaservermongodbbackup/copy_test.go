@@ -0,0 +1,198 @@
+package perconaservermongodbbackup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pbmBackup "github.com/percona/percona-backup-mongodb/pbm/backup"
+	"github.com/percona/percona-backup-mongodb/pbm/config"
+	"github.com/percona/percona-backup-mongodb/pbm/storage"
+	"github.com/percona/percona-backup-mongodb/pbm/storage/s3"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+)
+
+type memStorage struct {
+	files map[string][]byte
+	saved []string
+}
+
+func (s *memStorage) Type() storage.Type { return storage.Blackhole }
+
+func (s *memStorage) Save(name string, data io.Reader, size int64) error {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	s.files[name] = b
+	s.saved = append(s.saved, name)
+	return nil
+}
+
+func (s *memStorage) SourceReader(name string) (io.ReadCloser, error) {
+	b, ok := s.files[name]
+	if !ok {
+		return nil, storage.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s *memStorage) FileStat(name string) (storage.FileInfo, error) {
+	b, ok := s.files[name]
+	if !ok {
+		return storage.FileInfo{}, storage.ErrNotExist
+	}
+	return storage.FileInfo{Name: name, Size: int64(len(b))}, nil
+}
+
+func (s *memStorage) List(prefix, suffix string) ([]storage.FileInfo, error) {
+	files := []storage.FileInfo{}
+	for name, b := range s.files {
+		if strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix) {
+			files = append(files, storage.FileInfo{Name: strings.TrimPrefix(name, prefix), Size: int64(len(b))})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func (s *memStorage) Delete(name string) error {
+	delete(s.files, name)
+	return nil
+}
+
+func (s *memStorage) Copy(src, dst string) error {
+	s.files[dst] = s.files[src]
+	return nil
+}
+
+func TestCopyBackupFiles(t *testing.T) {
+	name := "2024-05-10T12:00:00Z"
+	src := &memStorage{files: map[string][]byte{
+		name + ".pbm.json":          []byte(`{"name":"` + name + `"}`),
+		name + "/rs0/metadata.json": []byte("meta"),
+		name + "/rs0/app.users.gz":  []byte("users"),
+		name + "/rs0/oplog/1-2.gz":  []byte("oplog"),
+		"2024-05-09T12:00:00Z/rs0/": []byte("another backup"),
+	}}
+	dst := &memStorage{files: map[string][]byte{
+		name + "/rs0/metadata.json": []byte("meta"),
+		name + "/rs0/app.users.gz":  []byte("partial"),
+	}}
+
+	store := pbmBackup.Storage{
+		Name:      "s3-copy",
+		IsProfile: true,
+		StorageConf: config.StorageConf{
+			Type: storage.S3,
+			S3:   &s3.Config{Bucket: "copy"},
+		},
+	}
+
+	if err := copyBackupFiles(context.Background(), src, dst, name, store); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		name + "/rs0/app.users.gz",
+		name + "/rs0/oplog/1-2.gz",
+		name + ".pbm.json",
+	}
+	if !reflect.DeepEqual(dst.saved, expected) {
+		t.Errorf("expected saved files %v, got %v", expected, dst.saved)
+	}
+	for _, f := range expected[:2] {
+		if !bytes.Equal(src.files[f], dst.files[f]) {
+			t.Errorf("file %s differs", f)
+		}
+	}
+
+	meta, err := pbmBackup.ReadMetadata(dst, name+".pbm.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Name != name || meta.Store.Name != "s3-copy" || !meta.Store.IsProfile || meta.Store.S3 == nil || meta.Store.S3.Bucket != "copy" {
+		t.Errorf("unexpected copied metadata: %+v", meta)
+	}
+
+	if err := copyBackupFiles(context.Background(), src, dst, "2024-05-11T12:00:00Z", store); err == nil {
+		t.Error("expected error for missing backup")
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	dst = &memStorage{files: map[string][]byte{}}
+	if err := copyBackupFiles(cancelled, src, dst, name, store); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled copy, got %v", err)
+	}
+	if len(dst.saved) != 0 {
+		t.Errorf("expected no saved files after cancel, got %v", dst.saved)
+	}
+}
+
+func TestRunCopyJob(t *testing.T) {
+	ctx := context.Background()
+
+	r := &ReconcilePerconaServerMongoDBBackup{copyJobs: new(sync.Map)}
+	cr := &psmdbv1.PerconaServerMongoDBBackup{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "backup", UID: "uid"}}
+
+	running := &copyJob{done: make(chan struct{})}
+	r.copyJobs.Store("ns/backup/uid/s3-copy", running)
+
+	done, err := r.runCopyJob(ctx, nil, cr, "s3-copy", psmdbv1.BackupStorageSpec{})
+	if done || err != nil {
+		t.Errorf("expected running copy, got done %t, error %v", done, err)
+	}
+
+	running.err = errors.New("copy failed")
+	close(running.done)
+
+	done, err = r.runCopyJob(ctx, nil, cr, "s3-copy", psmdbv1.BackupStorageSpec{})
+	if !done || err == nil {
+		t.Errorf("expected failed copy, got done %t, error %v", done, err)
+	}
+	if _, ok := r.copyJobs.Load("ns/backup/uid/s3-copy"); ok {
+		t.Error("finished copy job is not removed")
+	}
+}
+
+func TestCancelCopyJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	copyJobs := new(sync.Map)
+	jobs := map[string]context.Context{}
+	for _, key := range []string{"ns/backup/uid/s3-copy", "ns/backup/old-uid/s3-copy", "ns/backup-2/uid-2/s3-copy"} {
+		jobCtx, jobCancel := context.WithCancel(ctx)
+		jobs[key] = jobCtx
+		copyJobs.Store(key, &copyJob{done: make(chan struct{}), cancel: jobCancel})
+	}
+
+	cancelCopyJobs(copyJobs, copyJobsPrefix("ns", "backup", "uid"))
+	if jobs["ns/backup/uid/s3-copy"].Err() == nil {
+		t.Error("copy job of the deleted backup is not cancelled")
+	}
+	if _, ok := copyJobs.Load("ns/backup/uid/s3-copy"); ok {
+		t.Error("cancelled copy job is not removed")
+	}
+	if jobs["ns/backup/old-uid/s3-copy"].Err() != nil || jobs["ns/backup-2/uid-2/s3-copy"].Err() != nil {
+		t.Error("copy jobs of other backups are cancelled")
+	}
+
+	cancelCopyJobs(copyJobs, copyJobsPrefix("ns", "backup", ""))
+	if jobs["ns/backup/old-uid/s3-copy"].Err() == nil {
+		t.Error("copy job of the backup with the same name is not cancelled")
+	}
+	if jobs["ns/backup-2/uid-2/s3-copy"].Err() != nil {
+		t.Error("copy job of other backup is cancelled")
+	}
+}
@@ -0,0 +1,291 @@
+package perconaservermongodbbackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+)
+
+const (
+	defaultHookTimeout = time.Minute
+
+	// maxHookOutputLen limits the length of the hook output saved in the backup status.
+	maxHookOutputLen = 1024
+)
+
+// runHooks runs hooks of the given phase one by one and returns their results.
+// It returns an error if a hook with the abort failure policy fails.
+// Remaining hooks are marked as skipped in this case.
+func (r *ReconcilePerconaServerMongoDBBackup) runHooks(
+	ctx context.Context,
+	cr *psmdbv1.PerconaServerMongoDBBackup,
+	phase psmdbv1.BackupHookPhase,
+) ([]psmdbv1.BackupHookStatus, error) {
+	log := logf.FromContext(ctx)
+
+	if cr.Spec.Hooks == nil {
+		return nil, nil
+	}
+
+	hooks := cr.Spec.Hooks.Pre
+	if phase == psmdbv1.BackupHookPhasePost {
+		hooks = cr.Spec.Hooks.Post
+	}
+
+	var results []psmdbv1.BackupHookStatus
+	var abortErr error
+	for _, hook := range hooks {
+		if abortErr != nil {
+			results = append(results, psmdbv1.BackupHookStatus{
+				Name:  hook.Name,
+				Phase: phase,
+				State: psmdbv1.BackupHookSkipped,
+			})
+			continue
+		}
+
+		start := metav1.Now()
+		out, err := r.runHook(ctx, cr, hook)
+		end := metav1.Now()
+
+		res := psmdbv1.BackupHookStatus{
+			Name:        hook.Name,
+			Phase:       phase,
+			State:       psmdbv1.BackupHookSucceeded,
+			StartAt:     &start,
+			CompletedAt: &end,
+			Output:      truncateHookOutput(out),
+		}
+		if err != nil {
+			res.State = psmdbv1.BackupHookFailed
+			res.Error = err.Error()
+			log.Error(err, "backup hook failed", "backup", cr.Name, "hook", hook.Name, "phase", phase)
+
+			if hook.FailurePolicy != psmdbv1.BackupHookFailurePolicyContinue {
+				abortErr = errors.Wrapf(err, "%s-backup hook %s", phase, hook.Name)
+			}
+		} else {
+			log.Info("Backup hook succeeded", "backup", cr.Name, "hook", hook.Name, "phase", phase)
+		}
+		results = append(results, res)
+	}
+
+	return results, abortErr
+}
+
+func (r *ReconcilePerconaServerMongoDBBackup) runHook(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBBackup, hook psmdbv1.BackupHook) (string, error) {
+	timeout := defaultHookTimeout
+	if hook.Timeout != nil && hook.Timeout.Duration > 0 {
+		timeout = hook.Timeout.Duration
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch {
+	case hook.Exec != nil:
+		return r.runExecHook(ctx, cr, hook.Exec)
+	case hook.HTTP != nil:
+		return runHTTPHook(ctx, cr, hook.HTTP, timeout)
+	}
+
+	return "", errors.New("neither exec nor http is specified")
+}
+
+func (r *ReconcilePerconaServerMongoDBBackup) runExecHook(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBBackup, hook *psmdbv1.BackupHookExec) (string, error) {
+	pod, err := r.hookPod(ctx, cr, hook)
+	if err != nil {
+		return "", err
+	}
+
+	var outB, errB bytes.Buffer
+	err = r.clientcmd.Exec(ctx, pod, hook.Container, hook.Command, nil, &outB, &errB, false)
+	if err != nil {
+		return outB.String(), errors.Wrapf(err, "exec in %s/%s: %s", pod.Name, hook.Container, errB.String())
+	}
+
+	return outB.String(), nil
+}
+
+// hookPod returns the pod to run the exec hook in. Hooks can run only in pods of the backup's cluster.
+func (r *ReconcilePerconaServerMongoDBBackup) hookPod(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBBackup, hook *psmdbv1.BackupHookExec) (*corev1.Pod, error) {
+	clusterName := cr.Spec.GetClusterName()
+	clusterPods := labels.SelectorFromSet(naming.ClusterLabels(&psmdbv1.PerconaServerMongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterName},
+	}))
+
+	if hook.Pod != "" {
+		pod := new(corev1.Pod)
+		if err := r.client.Get(ctx, types.NamespacedName{Name: hook.Pod, Namespace: cr.Namespace}, pod); err != nil {
+			return nil, errors.Wrapf(err, "get pod %s", hook.Pod)
+		}
+		if !clusterPods.Matches(labels.Set(pod.Labels)) {
+			return nil, errors.Errorf("pod %s doesn't belong to cluster %s", hook.Pod, clusterName)
+		}
+		return pod, nil
+	}
+
+	if hook.Selector == nil {
+		return nil, errors.New("neither pod nor selector is specified")
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(hook.Selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse selector")
+	}
+
+	pods := new(corev1.PodList)
+	err = r.client.List(ctx, pods, &client.ListOptions{
+		Namespace:     cr.Namespace,
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list pods")
+	}
+
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning && clusterPods.Matches(labels.Set(pods.Items[i].Labels)) {
+			return &pods.Items[i], nil
+		}
+	}
+
+	return nil, errors.Errorf("no running pods of cluster %s match selector %s", clusterName, selector.String())
+}
+
+type hookPayload struct {
+	Backup      string                     `json:"backup"`
+	Namespace   string                     `json:"namespace"`
+	Cluster     string                     `json:"cluster"`
+	StorageName string                     `json:"storageName"`
+	State       psmdbv1.BackupState        `json:"state"`
+	Destination string                     `json:"destination,omitempty"`
+	Error       string                     `json:"error,omitempty"`
+	Size        string                     `json:"size,omitempty"`
+	CompletedAt *metav1.Time               `json:"completed,omitempty"`
+	Hooks       []psmdbv1.BackupHookStatus `json:"hooks,omitempty"`
+}
+
+var hookAddrAllowed = defaultHookAddrAllowed
+
+// defaultHookAddrAllowed returns false for addresses http hooks can't be sent to:
+// loopback addresses of the operator pod and link-local addresses, which include cloud metadata endpoints.
+func defaultHookAddrAllowed(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsUnspecified() && !ip.IsMulticast()
+}
+
+// newHookHTTPClient returns the client for http hooks. The client checks addresses it connects to
+// after name resolution, doesn't use proxies and doesn't follow redirects.
+func newHookHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return errors.Wrapf(err, "parse address %s", host)
+			}
+			if !hookAddrAllowed(ip) {
+				return errors.Errorf("destination %s is not allowed", ip)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func runHTTPHook(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBBackup, hook *psmdbv1.BackupHookHTTP, timeout time.Duration) (string, error) {
+	body := []byte(hook.Body)
+	if hook.Body == "" {
+		var err error
+		body, err = json.Marshal(hookPayload{
+			Backup:      cr.Name,
+			Namespace:   cr.Namespace,
+			Cluster:     cr.Spec.GetClusterName(),
+			StorageName: cr.Spec.StorageName,
+			State:       cr.Status.State,
+			Destination: cr.Status.Destination,
+			Error:       cr.Status.Error,
+			Size:        cr.Status.Size,
+			CompletedAt: cr.Status.CompletedAt,
+			Hooks:       cr.Status.Hooks,
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "marshal payload")
+		}
+	}
+
+	method := hook.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", errors.Wrap(err, "create request")
+	}
+	if hook.Body == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := newHookHTTPClient(timeout).Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "send request")
+	}
+	defer resp.Body.Close()
+
+	out, err := io.ReadAll(io.LimitReader(resp.Body, maxHookOutputLen))
+	if err != nil {
+		return "", errors.Wrap(err, "read response")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return string(out), errors.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	return string(out), nil
+}
+
+func truncateHookOutput(out string) string {
+	out = strings.TrimSpace(out)
+	if len(out) > maxHookOutputLen {
+		return out[:maxHookOutputLen] + "..."
+	}
+	return out
+}
@@ -0,0 +1,233 @@
+package perconaservermongodbbackup
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+)
+
+func TestRunHooks(t *testing.T) {
+	ctx := context.Background()
+
+	// test server listens on loopback
+	defer func(f func(netip.Addr) bool) { hookAddrAllowed = f }(hookAddrAllowed)
+	hookAddrAllowed = func(netip.Addr) bool { return true }
+
+	var payloads []hookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		p := hookPayload{}
+		if err := json.Unmarshal(b, &p); err == nil {
+			payloads = append(payloads, p)
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	hook := func(name, path string, policy psmdbv1.BackupHookFailurePolicy) psmdbv1.BackupHook {
+		return psmdbv1.BackupHook{
+			Name:          name,
+			HTTP:          &psmdbv1.BackupHookHTTP{URL: srv.URL + path},
+			FailurePolicy: policy,
+		}
+	}
+
+	tests := map[string]struct {
+		hooks    []psmdbv1.BackupHook
+		states   []psmdbv1.BackupHookState
+		expected bool
+	}{
+		"succeeded": {
+			hooks:  []psmdbv1.BackupHook{hook("notify", "/", "")},
+			states: []psmdbv1.BackupHookState{psmdbv1.BackupHookSucceeded},
+		},
+		"failed with continue policy": {
+			hooks: []psmdbv1.BackupHook{
+				hook("fail", "/fail", psmdbv1.BackupHookFailurePolicyContinue),
+				hook("notify", "/", ""),
+			},
+			states: []psmdbv1.BackupHookState{psmdbv1.BackupHookFailed, psmdbv1.BackupHookSucceeded},
+		},
+		"failed with abort policy": {
+			hooks: []psmdbv1.BackupHook{
+				hook("fail", "/fail", psmdbv1.BackupHookFailurePolicyAbort),
+				hook("notify", "/", ""),
+			},
+			states:   []psmdbv1.BackupHookState{psmdbv1.BackupHookFailed, psmdbv1.BackupHookSkipped},
+			expected: true,
+		},
+	}
+
+	r := new(ReconcilePerconaServerMongoDBBackup)
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			payloads = nil
+			cr := &psmdbv1.PerconaServerMongoDBBackup{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+				Spec: psmdbv1.PerconaServerMongoDBBackupSpec{
+					ClusterName: "cluster",
+					StorageName: "s3",
+					Hooks:       &psmdbv1.BackupHooks{Post: tt.hooks},
+				},
+				Status: psmdbv1.PerconaServerMongoDBBackupStatus{
+					State:       psmdbv1.BackupStateReady,
+					Destination: "s3://bucket/backup",
+				},
+			}
+
+			results, err := r.runHooks(ctx, cr, psmdbv1.BackupHookPhasePost)
+			if (err != nil) != tt.expected {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != len(tt.states) {
+				t.Fatalf("expected %d results, got %d", len(tt.states), len(results))
+			}
+			for i, res := range results {
+				if res.State != tt.states[i] {
+					t.Errorf("hook %s: expected state %s, got %s", res.Name, tt.states[i], res.State)
+				}
+				if res.Phase != psmdbv1.BackupHookPhasePost {
+					t.Errorf("hook %s: unexpected phase %s", res.Name, res.Phase)
+				}
+			}
+			for _, p := range payloads {
+				if p.Backup != "backup" || p.State != psmdbv1.BackupStateReady || p.Destination != "s3://bucket/backup" {
+					t.Errorf("unexpected payload: %+v", p)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPHookDestination(t *testing.T) {
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/redirect" {
+			http.Redirect(w, req, "/", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cr := &psmdbv1.PerconaServerMongoDBBackup{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"}}
+
+	if _, err := runHTTPHook(ctx, cr, &psmdbv1.BackupHookHTTP{URL: srv.URL}, time.Second); err == nil || !strings.Contains(err.Error(), "is not allowed") {
+		t.Errorf("expected loopback destination to be rejected, got %v", err)
+	}
+
+	defer func(f func(netip.Addr) bool) { hookAddrAllowed = f }(hookAddrAllowed)
+	hookAddrAllowed = func(netip.Addr) bool { return true }
+
+	if _, err := runHTTPHook(ctx, cr, &psmdbv1.BackupHookHTTP{URL: srv.URL + "/redirect"}, time.Second); err == nil {
+		t.Error("expected redirect not to be followed")
+	}
+
+	tests := map[string]bool{
+		"10.0.0.10":          true,
+		"203.0.113.5":        true,
+		"127.0.0.1":          false,
+		"::1":                false,
+		"169.254.169.254":    false,
+		"::ffff:169.254.1.1": false,
+		"fe80::1":            false,
+		"0.0.0.0":            false,
+	}
+	for addr, expected := range tests {
+		if allowed := defaultHookAddrAllowed(netip.MustParseAddr(addr)); allowed != expected {
+			t.Errorf("%s: expected allowed %t, got %t", addr, expected, allowed)
+		}
+	}
+}
+
+func TestHookPod(t *testing.T) {
+	ctx := context.Background()
+
+	clusterLabels := naming.ClusterLabels(&psmdbv1.PerconaServerMongoDB{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}})
+	pod := func(name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Labels: labels},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	rsLabels := map[string]string{naming.LabelKubernetesReplset: "rs0"}
+	for k, v := range clusterLabels {
+		rsLabels[k] = v
+	}
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		pod("cluster-rs0-0", rsLabels),
+		pod("app", map[string]string{naming.LabelKubernetesReplset: "rs0"}),
+	).Build()
+	r := &ReconcilePerconaServerMongoDBBackup{client: cl}
+
+	cr := &psmdbv1.PerconaServerMongoDBBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+		Spec:       psmdbv1.PerconaServerMongoDBBackupSpec{ClusterName: "cluster"},
+	}
+
+	tests := map[string]struct {
+		hook     *psmdbv1.BackupHookExec
+		expected string
+	}{
+		"cluster pod": {
+			hook:     &psmdbv1.BackupHookExec{Pod: "cluster-rs0-0"},
+			expected: "cluster-rs0-0",
+		},
+		"other pod": {
+			hook: &psmdbv1.BackupHookExec{Pod: "app"},
+		},
+		"selector": {
+			hook: &psmdbv1.BackupHookExec{Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{naming.LabelKubernetesReplset: "rs0"},
+			}},
+			expected: "cluster-rs0-0",
+		},
+		"selector without cluster pods": {
+			hook: &psmdbv1.BackupHookExec{Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{naming.LabelKubernetesReplset: "rs1"},
+			}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := r.hookPod(ctx, cr, tt.hook)
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("expected error, got pod %s", p.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Name != tt.expected {
+				t.Errorf("expected pod %s, got %s", tt.expected, p.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package perconaservermongodbbackup
+
+import (
+	"context"
+	"slices"
+
+	"github.com/pkg/errors"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+)
+
+func isImported(cr *psmdbv1.PerconaServerMongoDBBackup) bool {
+	return cr.Labels[naming.LabelBackupType] == naming.BackupTypeImported
+}
+
+// reconcileImported keeps imported backups read-only: the delete-backup finalizer is removed
+// without deleting the data from the storage and the backup fails while its spec doesn't
+// describe the imported backup.
+func (r *ReconcilePerconaServerMongoDBBackup) reconcileImported(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBBackup) error {
+	log := logf.FromContext(ctx)
+
+	if cr.ObjectMeta.DeletionTimestamp != nil {
+		finalizers := []string{}
+		for _, f := range cr.GetFinalizers() {
+			switch f {
+			case "delete-backup", naming.FinalizerDeleteBackup:
+				log.Info("Data of imported backups is not deleted from the storage", "backup", cr.Name, "finalizer", f)
+			default:
+				finalizers = append(finalizers, f)
+			}
+		}
+		if len(finalizers) == len(cr.GetFinalizers()) {
+			return nil
+		}
+
+		cr.SetFinalizers(finalizers)
+		return errors.Wrap(r.client.Update(ctx, cr), "update finalizers")
+	}
+
+	status := cr.Status
+	if err := checkImportedSpec(cr); err != nil {
+		status.State = psmdbv1.BackupStateError
+		status.Error = err.Error()
+	} else if status.State == psmdbv1.BackupStateError {
+		status.State = psmdbv1.BackupStateReady
+		status.Error = ""
+	}
+
+	if status.State == cr.Status.State && status.Error == cr.Status.Error {
+		return nil
+	}
+
+	cr.Status = status
+	return r.updateStatus(ctx, cr)
+}
+
+// checkImportedSpec returns an error if the spec of the imported backup
+// was changed to describe another backup or to run backup hooks.
+func checkImportedSpec(cr *psmdbv1.PerconaServerMongoDBBackup) error {
+	switch {
+	case cr.Spec.GetClusterName() != cr.Labels[naming.LabelCluster]:
+		return errors.Errorf("imported backup is read-only: clusterName must be %s", cr.Labels[naming.LabelCluster])
+	case cr.Spec.StorageName != cr.Status.StorageName:
+		return errors.Errorf("imported backup is read-only: storageName must be %s", cr.Status.StorageName)
+	case cr.Spec.Type != cr.Status.Type:
+		return errors.Errorf("imported backup is read-only: type must be %s", cr.Status.Type)
+	case cr.Spec.IncrementalBase:
+		return errors.New("imported backup is read-only: incrementalBase can't be set")
+	case !slices.Equal(cr.Spec.Namespaces, cr.Status.Namespaces):
+		return errors.Errorf("imported backup is read-only: namespaces must be %v", cr.Status.Namespaces)
+	case cr.Spec.Hooks != nil && (len(cr.Spec.Hooks.Pre) > 0 || len(cr.Spec.Hooks.Post) > 0):
+		return errors.New("imported backup is read-only: hooks can't be set")
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package perconaservermongodbbackup
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+)
+
+func importedBackup() *psmdbv1.PerconaServerMongoDBBackup {
+	return &psmdbv1.PerconaServerMongoDBBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-2024-10-01t10-00-00z",
+			Namespace: "ns",
+			Labels: map[string]string{
+				naming.LabelCluster:    "cluster",
+				naming.LabelBackupType: naming.BackupTypeImported,
+			},
+		},
+		Spec: psmdbv1.PerconaServerMongoDBBackupSpec{
+			ClusterName: "cluster",
+			StorageName: "s3",
+			Type:        defs.LogicalBackup,
+			Namespaces:  []string{"app.*"},
+		},
+		Status: psmdbv1.PerconaServerMongoDBBackupStatus{
+			State:       psmdbv1.BackupStateReady,
+			StorageName: "s3",
+			Type:        defs.LogicalBackup,
+			Namespaces:  []string{"app.*"},
+			PBMname:     "2024-10-01T10:00:00Z",
+		},
+	}
+}
+
+func fakeBackupReconciler(objs ...client.Object) *ReconcilePerconaServerMongoDBBackup {
+	s := scheme.Scheme
+	s.AddKnownTypes(psmdbv1.SchemeGroupVersion,
+		new(psmdbv1.PerconaServerMongoDBBackup),
+		new(psmdbv1.PerconaServerMongoDBBackupList),
+	)
+
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).WithStatusSubresource(objs...).Build()
+
+	return &ReconcilePerconaServerMongoDBBackup{client: cl, scheme: s}
+}
+
+func TestReconcileImported(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		modify func(cr *psmdbv1.PerconaServerMongoDBBackup)
+		state  psmdbv1.BackupState
+		err    string
+	}{
+		"unchanged": {
+			modify: func(cr *psmdbv1.PerconaServerMongoDBBackup) {},
+			state:  psmdbv1.BackupStateReady,
+		},
+		"ttl": {
+			modify: func(cr *psmdbv1.PerconaServerMongoDBBackup) {
+				cr.Spec.TTL = &metav1.Duration{}
+			},
+			state: psmdbv1.BackupStateReady,
+		},
+		"storage": {
+			modify: func(cr *psmdbv1.PerconaServerMongoDBBackup) {
+				cr.Spec.StorageName = "other"
+			},
+			state: psmdbv1.BackupStateError,
+			err:   "imported backup is read-only: storageName must be s3",
+		},
+		"type": {
+			modify: func(cr *psmdbv1.PerconaServerMongoDBBackup) {
+				cr.Spec.Type = defs.PhysicalBackup
+			},
+			state: psmdbv1.BackupStateError,
+			err:   "imported backup is read-only: type must be logical",
+		},
+		"namespaces": {
+			modify: func(cr *psmdbv1.PerconaServerMongoDBBackup) {
+				cr.Spec.Namespaces = nil
+			},
+			state: psmdbv1.BackupStateError,
+			err:   "imported backup is read-only: namespaces must be [app.*]",
+		},
+		"hooks": {
+			modify: func(cr *psmdbv1.PerconaServerMongoDBBackup) {
+				cr.Spec.Hooks = &psmdbv1.BackupHooks{Post: []psmdbv1.BackupHook{{Name: "notify"}}}
+			},
+			state: psmdbv1.BackupStateError,
+			err:   "imported backup is read-only: hooks can't be set",
+		},
+		"reverted": {
+			modify: func(cr *psmdbv1.PerconaServerMongoDBBackup) {
+				cr.Status.State = psmdbv1.BackupStateError
+				cr.Status.Error = "imported backup is read-only: storageName must be s3"
+			},
+			state: psmdbv1.BackupStateReady,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cr := importedBackup()
+			tt.modify(cr)
+
+			r := fakeBackupReconciler(cr)
+			if err := r.reconcileImported(ctx, cr); err != nil {
+				t.Fatal(err)
+			}
+
+			got := new(psmdbv1.PerconaServerMongoDBBackup)
+			if err := r.client.Get(ctx, client.ObjectKeyFromObject(cr), got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Status.State != tt.state || got.Status.Error != tt.err {
+				t.Errorf("expected state %q with error %q, got %q with error %q", tt.state, tt.err, got.Status.State, got.Status.Error)
+			}
+		})
+	}
+}
+
+func TestReconcileImportedDeletion(t *testing.T) {
+	ctx := context.Background()
+
+	cr := importedBackup()
+	cr.Finalizers = []string{naming.FinalizerDeleteBackup, "example.com/keep"}
+
+	r := fakeBackupReconciler(cr)
+	if err := r.client.Delete(ctx, cr); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(cr), cr); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcileImported(ctx, cr); err != nil {
+		t.Fatal(err)
+	}
+
+	got := new(psmdbv1.PerconaServerMongoDBBackup)
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(cr), got); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"example.com/keep"}; !slices.Equal(got.Finalizers, expected) {
+		t.Errorf("expected finalizers %v, got %v", expected, got.Finalizers)
+	}
+}
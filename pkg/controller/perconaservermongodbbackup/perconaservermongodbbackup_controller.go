@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -13,6 +15,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -22,6 +25,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	pbmBackup "github.com/percona/percona-backup-mongodb/pbm/backup"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
 	pbmErrors "github.com/percona/percona-backup-mongodb/pbm/errors"
 	"github.com/percona/percona-backup-mongodb/pbm/storage"
 	"github.com/percona/percona-backup-mongodb/pbm/storage/azure"
@@ -31,6 +35,7 @@ import (
 	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/metrics"
 	"github.com/percona/percona-server-mongodb-operator/version"
 )
 
@@ -61,6 +66,8 @@ func newReconciler(mgr manager.Manager) (reconcile.Reconciler, error) {
 		scheme:     mgr.GetScheme(),
 		newPBMFunc: backup.NewPBM,
 		clientcmd:  cli,
+		recorder:   mgr.GetEventRecorderFor("psmdbbackup-controller"),
+		copyJobs:   new(sync.Map),
 	}, nil
 }
 
@@ -90,8 +97,12 @@ type ReconcilePerconaServerMongoDBBackup struct {
 	client    client.Client
 	scheme    *runtime.Scheme
 	clientcmd *clientcmd.Client
+	recorder  record.EventRecorder
 
 	newPBMFunc backup.NewPBMFunc
+
+	// copyJobs keeps running copies of backups to other storages
+	copyJobs *sync.Map
 }
 
 // Reconcile reads that state of the cluster for a PerconaServerMongoDBBackup object and makes changes based on the state read
@@ -113,14 +124,37 @@ func (r *ReconcilePerconaServerMongoDBBackup) Reconcile(ctx context.Context, req
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			cancelCopyJobs(r.copyJobs, copyJobsPrefix(request.Namespace, request.Name, ""))
 			return rr, nil
 		}
 		// Error reading the object - requeue the request.
 		return rr, err
 	}
 
+	if cr.ObjectMeta.DeletionTimestamp == nil && cr.Expired(time.Now()) {
+		log.Info("Deleting expired backup", "backup", cr.Name)
+		if err := r.client.Delete(ctx, cr); err != nil && !k8serrors.IsNotFound(err) {
+			return rr, errors.Wrap(err, "delete expired backup")
+		}
+		return rr, nil
+	}
+
+	if isImported(cr) {
+		// status of imported backups is set right after they are created
+		if cr.Status.State == psmdbv1.BackupStateNew {
+			return rr, nil
+		}
+		if err := r.reconcileImported(ctx, cr); err != nil {
+			return rr, errors.Wrap(err, "reconcile imported backup")
+		}
+		if cr.ObjectMeta.DeletionTimestamp != nil || cr.Status.State != psmdbv1.BackupStateReady {
+			return rr, nil
+		}
+	}
+
 	if (cr.Status.State == psmdbv1.BackupStateReady || cr.Status.State == psmdbv1.BackupStateError) &&
-		cr.ObjectMeta.DeletionTimestamp == nil {
+		cr.ObjectMeta.DeletionTimestamp == nil &&
+		!cr.PostHooksPending() && !cr.VerificationPending() && !cr.CopyPending() {
 		return rr, nil
 	}
 
@@ -132,15 +166,30 @@ func (r *ReconcilePerconaServerMongoDBBackup) Reconcile(ctx context.Context, req
 			status.Error = err.Error()
 			log.Error(err, "failed to make backup", "backup", cr.Name)
 		}
-		if cr.Status.State != status.State || cr.Status.Error != status.Error {
+		if cr.Status.State != status.State || cr.Status.Error != status.Error ||
+			!reflect.DeepEqual(cr.Status.Verification, status.Verification) ||
+			!reflect.DeepEqual(cr.Status.Copies, status.Copies) ||
+			!reflect.DeepEqual(cr.Status.Hooks, status.Hooks) {
+			stateChanged := cr.Status.State != status.State
 			cr.Status = status
 			uerr := r.updateStatus(ctx, cr)
 			if uerr != nil {
 				log.Error(uerr, "failed to update backup status", "backup", cr.Name)
+			} else if stateChanged {
+				metrics.ObserveBackup(cr)
 			}
 		}
 	}()
 
+	if cr.PostHooksPending() && cr.ObjectMeta.DeletionTimestamp == nil {
+		hooks, herr := r.runHooks(ctx, cr, psmdbv1.BackupHookPhasePost)
+		if herr != nil {
+			log.Error(herr, "failed to run post-backup hooks", "backup", cr.Name)
+		}
+		status.Hooks = append(status.Hooks, hooks...)
+		return rr, nil
+	}
+
 	err = cr.CheckFields()
 	if err != nil {
 		return rr, errors.Wrap(err, "fields check")
@@ -168,6 +217,24 @@ func (r *ReconcilePerconaServerMongoDBBackup) Reconcile(ctx context.Context, req
 		}
 	}
 
+	if cr.CopyPending() && cr.ObjectMeta.DeletionTimestamp == nil {
+		var cerr error
+		status.Copies, cerr = r.reconcileCopies(ctx, cluster, cr)
+		if cerr != nil {
+			log.Error(cerr, "failed to copy backup", "backup", cr.Name)
+		}
+		return rr, nil
+	}
+
+	if cr.VerificationPending() && cr.ObjectMeta.DeletionTimestamp == nil {
+		var verr error
+		status.Verification, verr = r.reconcileVerification(ctx, cluster, cr)
+		if verr != nil {
+			log.Error(verr, "failed to verify backup", "backup", cr.Name)
+		}
+		return rr, nil
+	}
+
 	bcp, err := r.newBackup(ctx, cluster)
 	if err != nil {
 		return rr, errors.Wrap(err, "create backup object")
@@ -204,6 +271,10 @@ func (r *ReconcilePerconaServerMongoDBBackup) reconcile(
 		return status, errors.New("cluster not found")
 	}
 
+	if _, ok := cr.Annotations[psmdbv1.AnnotationCancelBackup]; ok {
+		return cancelBackup(ctx, cr, bcp)
+	}
+
 	if err := cluster.CanBackup(ctx); err != nil {
 		return status, errors.Wrap(err, "failed to run backup")
 	}
@@ -223,13 +294,77 @@ func (r *ReconcilePerconaServerMongoDBBackup) reconcile(
 
 	if cr.Status.State == psmdbv1.BackupStateNew || cr.Status.State == psmdbv1.BackupStateWaiting {
 		time.Sleep(10 * time.Second)
-		return bcp.Start(ctx, r.client, cluster, cr)
+
+		hooks, err := r.runHooks(ctx, cr, psmdbv1.BackupHookPhasePre)
+		if err != nil {
+			status.Hooks = hooks
+			return status, err
+		}
+
+		status, err = bcp.Start(ctx, r.client, cluster, cr)
+		status.Hooks = hooks
+		return status, err
 	}
 
 	time.Sleep(5 * time.Second)
+	status, err = bcp.Status(ctx, cr)
+	if err != nil {
+		return status, err
+	}
+
+	if status.State == psmdbv1.BackupStateReady && status.Type != defs.LogicalBackup && status.ReplsetSizes == nil {
+		if err := r.setFilesStatsFromStorage(ctx, cluster, cr, &status); err != nil {
+			log.Error(err, "failed to get backup files from storage", "backup", cr.Name)
+		}
+	}
+
+	return status, nil
+}
+
+// cancelBackup cancels the backup requested by the user with the cancel-backup annotation.
+// Backups that are not started yet are marked as failed without involving PBM.
+// Started backups are canceled by PBM and failed once PBM reports them as canceled,
+// their locks are released if PBM doesn't cancel them in pbmCancelDeadline.
+func cancelBackup(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBBackup, bcp *Backup) (psmdbv1.PerconaServerMongoDBBackupStatus, error) {
+	status := cr.Status
+	if cr.Status.State == psmdbv1.BackupStateNew || cr.Status.State == psmdbv1.BackupStateWaiting || cr.Status.PBMname == "" {
+		status.State = psmdbv1.BackupStateError
+		status.Error = canceledByUserErrMsg
+		return status, nil
+	}
+
 	return bcp.Status(ctx, cr)
 }
 
+// setFilesStatsFromStorage reads the lists of physical backup files from the storage
+// and sets per-replset sizes and the compression ratio in the status.
+func (r *ReconcilePerconaServerMongoDBBackup) setFilesStatsFromStorage(
+	ctx context.Context,
+	cluster *psmdbv1.PerconaServerMongoDB,
+	cr *psmdbv1.PerconaServerMongoDBBackup,
+	status *psmdbv1.PerconaServerMongoDBBackupStatus,
+) error {
+	bcp := cr.DeepCopy()
+	bcp.Status = *status
+
+	stg, err := r.getPBMStorage(ctx, cluster, bcp)
+	if err != nil {
+		return errors.Wrap(err, "get storage")
+	}
+
+	files := make(map[string][]pbmBackup.File)
+	for rs := range status.PBMPods {
+		filelist, err := pbmBackup.ReadFilelistForReplset(stg, status.PBMname, rs)
+		if err != nil {
+			return errors.Wrapf(err, "read filelist for replset %s", rs)
+		}
+		files[rs] = filelist
+	}
+	setFilesStats(status, files)
+
+	return nil
+}
+
 func (r *ReconcilePerconaServerMongoDBBackup) getPBMStorage(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, cr *psmdbv1.PerconaServerMongoDBBackup) (storage.Storage, error) {
 	switch {
 	case cr.Status.Azure != nil:
@@ -356,6 +491,8 @@ func (r *ReconcilePerconaServerMongoDBBackup) checkFinalizers(ctx context.Contex
 		return nil
 	}
 
+	cancelCopyJobs(r.copyJobs, copyJobsPrefix(cr.Namespace, cr.Name, cr.UID))
+
 	finalizers := []string{}
 
 	if cr.Status.State == psmdbv1.BackupStateReady {
@@ -413,6 +550,18 @@ func (r *ReconcilePerconaServerMongoDBBackup) deleteBackupFinalizer(ctx context.
 		return errors.Errorf("PerconaServerMongoDB %s is not found", cr.Spec.GetClusterName())
 	}
 
+	// PBM deletes incremental backups only as a whole chain starting from its base,
+	// the next increments of the chain can't be restored without this one.
+	// The data of the increment is kept in storage and is deleted together with the base.
+	if meta.Type == defs.IncrementalBackup && meta.SrcBackup != "" {
+		log.Info("Incremental backup can't be deleted separately from its chain, keeping its data in storage", "base", meta.SrcBackup)
+		if r.recorder != nil {
+			r.recorder.Eventf(cr, corev1.EventTypeWarning, "BackupDataKept",
+				"Incremental backup %s is kept in storage: it is deleted only together with the base backup of its chain", cr.Status.PBMname)
+		}
+		return nil
+	}
+
 	var storage psmdbv1.BackupStorageSpec
 	switch {
 	case cr.Status.S3 != nil:
@@ -429,18 +578,22 @@ func (r *ReconcilePerconaServerMongoDBBackup) deleteBackupFinalizer(ctx context.
 		return nil
 	}
 
-	err = b.pbm.GetNSetConfig(ctx, r.client, cluster, storage)
-	if err != nil {
-		return errors.Wrapf(err, "set backup config with storage %s", cr.Spec.StorageName)
-	}
+	// Backups in config profiles are deleted using storage config from their metadata.
+	// They are not a base for the PITR timeline, which is kept in the main storage.
+	if !meta.Store.IsProfile {
+		err = b.pbm.GetNSetConfig(ctx, r.client, cluster, storage)
+		if err != nil {
+			return errors.Wrapf(err, "set backup config with storage %s", cr.Spec.StorageName)
+		}
 
-	// We should delete PITR oplog chunks until `LastWriteTS` of the backup,
-	// as it's not possible to delete backup if it is a base for the PITR timeline
-	err = b.pbm.DeletePITRChunks(ctx, meta.LastWriteTS)
-	if err != nil {
-		return errors.Wrap(err, "failed to delete PITR")
+		// We should delete PITR oplog chunks until `LastWriteTS` of the backup,
+		// as it's not possible to delete backup if it is a base for the PITR timeline
+		err = b.pbm.DeletePITRChunks(ctx, meta.LastWriteTS)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete PITR")
+		}
+		log.Info("PiTR chunks deleted", "until", meta.LastWriteTS)
 	}
-	log.Info("PiTR chunks deleted", "until", meta.LastWriteTS)
 
 	err = b.pbm.DeleteBackup(ctx, cr.Status.PBMname)
 	if err != nil {
@@ -448,9 +601,61 @@ func (r *ReconcilePerconaServerMongoDBBackup) deleteBackupFinalizer(ctx context.
 	}
 	log.Info("Backup deleted")
 
+	if meta.Type == defs.IncrementalBackup {
+		if err := r.deleteChainIncrements(ctx, cr); err != nil {
+			return errors.Wrap(err, "delete increments of the chain")
+		}
+	}
+
+	return nil
+}
+
+// deleteChainIncrements deletes objects of the increments of the chain, which is deleted
+// by PBM together with its base backup.
+func (r *ReconcilePerconaServerMongoDBBackup) deleteChainIncrements(ctx context.Context, base *psmdbv1.PerconaServerMongoDBBackup) error {
+	log := logf.FromContext(ctx)
+
+	bcps := psmdbv1.PerconaServerMongoDBBackupList{}
+	if err := r.client.List(ctx, &bcps, &client.ListOptions{Namespace: base.Namespace}); err != nil {
+		return errors.Wrap(err, "list backups")
+	}
+
+	for _, bcp := range chainIncrements(base, bcps.Items) {
+		log.Info("Deleting increment of the deleted chain", "backup", bcp.Name, "base", base.Name)
+		if err := r.client.Delete(ctx, &bcp); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "delete backup %s", bcp.Name)
+		}
+	}
+
 	return nil
 }
 
+// chainIncrements returns the backups of the cluster that are increments of the chain with the given base.
+func chainIncrements(base *psmdbv1.PerconaServerMongoDBBackup, bcps []psmdbv1.PerconaServerMongoDBBackup) []psmdbv1.PerconaServerMongoDBBackup {
+	inChain := map[string]struct{}{base.Status.PBMname: {}}
+
+	var increments []psmdbv1.PerconaServerMongoDBBackup
+	for found := true; found; {
+		found = false
+		for _, bcp := range bcps {
+			if bcp.Spec.GetClusterName() != base.Spec.GetClusterName() || bcp.Status.Type != defs.IncrementalBackup {
+				continue
+			}
+			if _, ok := inChain[bcp.Status.PBMname]; ok {
+				continue
+			}
+			if _, ok := inChain[bcp.Status.SrcBackup]; !ok || bcp.Status.SrcBackup == "" {
+				continue
+			}
+			inChain[bcp.Status.PBMname] = struct{}{}
+			increments = append(increments, bcp)
+			found = true
+		}
+	}
+
+	return increments
+}
+
 func (r *ReconcilePerconaServerMongoDBBackup) deleteFilesystemBackup(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, bcp *psmdbv1.PerconaServerMongoDBBackup) error {
 	log := logf.FromContext(ctx)
 
@@ -0,0 +1,386 @@
+package perconaservermongodbbackup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/mongo"
+	"github.com/percona/percona-server-mongodb-operator/version"
+)
+
+const (
+	verificationTimeout = 12 * time.Hour
+
+	// maxReportedInvalidCollections limits the number of invalid collections listed in the verification summary.
+	maxReportedInvalidCollections = 10
+)
+
+// reconcileVerification restores the backup into a temporary single-node replica set,
+// checks the restored data and removes the replica set. It returns the updated verification status.
+// The status is returned as is if the verification step fails and should be retried.
+func (r *ReconcilePerconaServerMongoDBBackup) reconcileVerification(
+	ctx context.Context,
+	cluster *psmdbv1.PerconaServerMongoDB,
+	cr *psmdbv1.PerconaServerMongoDBBackup,
+) (*psmdbv1.BackupVerificationStatus, error) {
+	log := logf.FromContext(ctx)
+
+	if cr.Status.Verification == nil {
+		return r.startVerification(ctx, cluster, cr)
+	}
+
+	v := cr.Status.Verification.DeepCopy()
+
+	if v.StartAt != nil && time.Since(v.StartAt.Time) > verificationTimeout {
+		return r.finishVerification(ctx, cr, v, false, fmt.Sprintf("verification is not finished in %s", verificationTimeout))
+	}
+
+	vcluster := new(psmdbv1.PerconaServerMongoDB)
+	err := r.client.Get(ctx, types.NamespacedName{Name: v.Cluster, Namespace: cr.Namespace}, vcluster)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return r.finishVerification(ctx, cr, v, false, fmt.Sprintf("temporary cluster %s is deleted", v.Cluster))
+		}
+		return cr.Status.Verification, errors.Wrapf(err, "get cluster %s", v.Cluster)
+	}
+
+	restore := new(psmdbv1.PerconaServerMongoDBRestore)
+	err = r.client.Get(ctx, types.NamespacedName{Name: v.Cluster, Namespace: cr.Namespace}, restore)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return cr.Status.Verification, errors.Wrapf(err, "get restore %s", v.Cluster)
+		}
+
+		if vcluster.Status.State != psmdbv1.AppStateReady {
+			return v, nil
+		}
+
+		restore = newVerificationRestore(cr, v.Cluster)
+		if err := controllerutil.SetControllerReference(cr, restore, r.scheme); err != nil {
+			return cr.Status.Verification, errors.Wrap(err, "set controller reference")
+		}
+		if err := r.client.Create(ctx, restore); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return cr.Status.Verification, errors.Wrapf(err, "create restore %s", restore.Name)
+		}
+		log.Info("Restoring backup into temporary cluster", "backup", cr.Name, "cluster", v.Cluster)
+
+		return v, nil
+	}
+
+	switch restore.Status.State {
+	case psmdbv1.RestoreStateError, psmdbv1.RestoreStateRejected:
+		return r.finishVerification(ctx, cr, v, false, "restore failed: "+restore.Status.Error)
+	case psmdbv1.RestoreStateReady:
+	default:
+		return v, nil
+	}
+
+	// cluster is restarted after physical restore
+	if vcluster.Status.State != psmdbv1.AppStateReady {
+		return v, nil
+	}
+
+	ok, summary, hashes, err := r.checkVerificationCluster(ctx, vcluster)
+	if err != nil {
+		return cr.Status.Verification, errors.Wrap(err, "check restored data")
+	}
+	v.DBHashes = hashes
+
+	return r.finishVerification(ctx, cr, v, ok, summary)
+}
+
+func (r *ReconcilePerconaServerMongoDBBackup) startVerification(
+	ctx context.Context,
+	cluster *psmdbv1.PerconaServerMongoDB,
+	cr *psmdbv1.PerconaServerMongoDBBackup,
+) (*psmdbv1.BackupVerificationStatus, error) {
+	log := logf.FromContext(ctx)
+
+	now := metav1.Now()
+	failed := func(summary string) *psmdbv1.BackupVerificationStatus {
+		log.Info("Backup verification failed", "backup", cr.Name, "summary", summary)
+		return &psmdbv1.BackupVerificationStatus{
+			State:       psmdbv1.BackupVerificationFailed,
+			StartAt:     &now,
+			CompletedAt: &now,
+			Summary:     summary,
+		}
+	}
+
+	if cluster == nil {
+		return failed("cluster not found"), nil
+	}
+	if cluster.Spec.Sharding.Enabled {
+		return failed("verification is supported only for unsharded clusters"), nil
+	}
+	if len(cluster.Spec.Replsets) == 0 {
+		return failed("cluster has no replsets"), nil
+	}
+	if stg, ok := cluster.Spec.Backup.Storages[cr.Spec.StorageName]; ok && stg.Type == psmdbv1.BackupStorageFilesystem && stg.Filesystem.PersistentVolumeClaim != nil {
+		return failed("verification is not supported for filesystem storages with persistentVolumeClaim, the volume can't be mounted into the temporary cluster"), nil
+	}
+
+	vcluster := newVerificationCluster(cluster, cr)
+
+	// Restored data has users of the cluster, the temporary cluster gets
+	// their credentials in its own secret, which is deleted with the cluster.
+	usersSecret := corev1.Secret{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: psmdbv1.UserSecretName(cluster), Namespace: cluster.Namespace}, &usersSecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "get users secret")
+	}
+	vsecret := newVerificationUsersSecret(vcluster, &usersSecret)
+	if err := controllerutil.SetControllerReference(cr, vsecret, r.scheme); err != nil {
+		return nil, errors.Wrap(err, "set controller reference")
+	}
+	if err := r.client.Create(ctx, vsecret); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return nil, errors.Wrapf(err, "create secret %s", vsecret.Name)
+	}
+
+	if err := controllerutil.SetControllerReference(cr, vcluster, r.scheme); err != nil {
+		return nil, errors.Wrap(err, "set controller reference")
+	}
+	if err := r.client.Create(ctx, vcluster); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return nil, errors.Wrapf(err, "create cluster %s", vcluster.Name)
+	}
+	log.Info("Starting backup verification", "backup", cr.Name, "cluster", vcluster.Name)
+
+	return &psmdbv1.BackupVerificationStatus{
+		State:   psmdbv1.BackupVerificationRunning,
+		Cluster: vcluster.Name,
+		StartAt: &now,
+	}, nil
+}
+
+// finishVerification deletes temporary objects and sets the final state of verification.
+func (r *ReconcilePerconaServerMongoDBBackup) finishVerification(
+	ctx context.Context,
+	cr *psmdbv1.PerconaServerMongoDBBackup,
+	v *psmdbv1.BackupVerificationStatus,
+	ok bool,
+	summary string,
+) (*psmdbv1.BackupVerificationStatus, error) {
+	log := logf.FromContext(ctx)
+
+	objs := []client.Object{
+		&psmdbv1.PerconaServerMongoDBRestore{ObjectMeta: metav1.ObjectMeta{Name: v.Cluster, Namespace: cr.Namespace}},
+		&psmdbv1.PerconaServerMongoDB{ObjectMeta: metav1.ObjectMeta{Name: v.Cluster, Namespace: cr.Namespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: verificationUsersSecretName(v.Cluster), Namespace: cr.Namespace}},
+	}
+	for _, obj := range objs {
+		if err := r.client.Delete(ctx, obj); err != nil && !k8serrors.IsNotFound(err) {
+			return cr.Status.Verification, errors.Wrapf(err, "delete %s", obj.GetName())
+		}
+	}
+
+	v.State = psmdbv1.BackupVerificationFailed
+	if ok {
+		v.State = psmdbv1.BackupVerificationOK
+	}
+	v.Summary = summary
+	now := metav1.Now()
+	v.CompletedAt = &now
+
+	log.Info("Backup verification finished", "backup", cr.Name, "state", v.State, "summary", summary)
+
+	return v, nil
+}
+
+func (r *ReconcilePerconaServerMongoDBBackup) checkVerificationCluster(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB) (bool, string, map[string]string, error) {
+	log := logf.FromContext(ctx)
+
+	svr, err := version.Server(r.clientcmd)
+	if err != nil {
+		return false, "", nil, errors.Wrap(err, "fetch server version")
+	}
+
+	if err := cluster.CheckNSetDefaults(svr.Platform, log); err != nil {
+		return false, "", nil, errors.Wrapf(err, "set defaults for %s/%s", cluster.Namespace, cluster.Name)
+	}
+
+	usersSecret := corev1.Secret{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: psmdbv1.UserSecretName(cluster), Namespace: cluster.Namespace}, &usersSecret)
+	if err != nil {
+		return false, "", nil, errors.Wrap(err, "get users secret")
+	}
+
+	creds := psmdb.Credentials{
+		Username: string(usersSecret.Data[psmdbv1.EnvMongoDBDatabaseAdminUser]),
+		Password: string(usersSecret.Data[psmdbv1.EnvMongoDBDatabaseAdminPassword]),
+	}
+
+	cli, err := psmdb.MongoClient(ctx, r.client, cluster, cluster.Spec.Replsets[0], creds)
+	if err != nil {
+		return false, "", nil, errors.Wrap(err, "get mongo client")
+	}
+	defer func() {
+		if err := cli.Disconnect(ctx); err != nil {
+			log.Error(err, "failed to close connection", "cluster", cluster.Name)
+		}
+	}()
+
+	return verifyData(ctx, cli)
+}
+
+// verifyData runs validate for each collection and dbHash for each database
+// except local and config. It returns false if any of collections is invalid.
+func verifyData(ctx context.Context, cli mongo.Client) (bool, string, map[string]string, error) {
+	dbs, err := cli.ListDBs(ctx)
+	if err != nil {
+		return false, "", nil, errors.Wrap(err, "list databases")
+	}
+
+	hashes := make(map[string]string)
+	invalid := []string{}
+	collections := 0
+	for _, db := range dbs.DBs {
+		if db.Name == "local" || db.Name == "config" {
+			continue
+		}
+
+		colls, err := cli.ListCollections(ctx, db.Name)
+		if err != nil {
+			return false, "", nil, errors.Wrapf(err, "list collections in %s", db.Name)
+		}
+
+		for _, coll := range colls {
+			resp, err := cli.ValidateCollection(ctx, db.Name, coll)
+			if err != nil {
+				return false, "", nil, errors.Wrapf(err, "validate %s.%s", db.Name, coll)
+			}
+			collections++
+
+			if !resp.Valid {
+				invalid = append(invalid, fmt.Sprintf("%s.%s (%s)", db.Name, coll, strings.Join(resp.Errors, "; ")))
+			}
+		}
+
+		hash, err := cli.DBHash(ctx, db.Name)
+		if err != nil {
+			return false, "", nil, errors.Wrapf(err, "dbHash %s", db.Name)
+		}
+		hashes[db.Name] = hash.MD5
+	}
+
+	if len(invalid) > 0 {
+		summary := fmt.Sprintf("%d of %d collections failed validation", len(invalid), collections)
+		if len(invalid) > maxReportedInvalidCollections {
+			invalid = append(invalid[:maxReportedInvalidCollections], "...")
+		}
+		return false, summary + ": " + strings.Join(invalid, ", "), hashes, nil
+	}
+
+	return true, fmt.Sprintf("%d collections in %d databases passed validation", collections, len(hashes)), hashes, nil
+}
+
+func verificationClusterName(cr *psmdbv1.PerconaServerMongoDBBackup) string {
+	uid := string(cr.UID)
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	return "verify-" + uid
+}
+
+func verificationUsersSecretName(clusterName string) string {
+	return clusterName + "-secrets"
+}
+
+// newVerificationCluster returns a single-node replica set based on the cluster spec.
+// It has only the storage of the backup and doesn't share users, TLS and keyfile secrets with the cluster.
+// The encryption key is shared since restored data is encrypted with it.
+func newVerificationCluster(cluster *psmdbv1.PerconaServerMongoDB, cr *psmdbv1.PerconaServerMongoDBBackup) *psmdbv1.PerconaServerMongoDB {
+	spec := cluster.Spec.DeepCopy()
+
+	rs := spec.Replsets[0]
+	rs.Size = 1
+	rs.Arbiter = psmdbv1.Arbiter{}
+	rs.NonVoting = psmdbv1.NonVotingSpec{}
+	rs.Expose = psmdbv1.ExposeTogglable{}
+	rs.ExternalNodes = nil
+	rs.Horizons = nil
+	rs.ReplsetOverrides = nil
+	spec.Replsets = []*psmdbv1.ReplsetSpec{rs}
+
+	spec.Pause = false
+	spec.Unmanaged = false
+	spec.Unsafe.ReplsetSize = true
+	if cluster.CompareVersion("1.16.0") < 0 {
+		spec.UnsafeConf = true
+	}
+	spec.Sharding = psmdbv1.Sharding{}
+	spec.MultiCluster = psmdbv1.MultiCluster{}
+	spec.PMM = psmdbv1.PMMSpec{}
+	spec.UpgradeOptions.Apply = psmdbv1.UpgradeStrategyDisabled
+	spec.Users = nil
+	spec.Roles = nil
+
+	if spec.Secrets == nil {
+		spec.Secrets = &psmdbv1.SecretsSpec{}
+	}
+	spec.Secrets.Users = verificationUsersSecretName(verificationClusterName(cr))
+	spec.Secrets.SSL = ""
+	spec.Secrets.SSLInternal = ""
+	spec.Secrets.InternalKey = ""
+
+	spec.Backup.Tasks = nil
+	spec.Backup.PITR = psmdbv1.PITRSpec{}
+	stg := spec.Backup.Storages[cr.Spec.StorageName]
+	stg.Main = false
+	spec.Backup.Storages = map[string]psmdbv1.BackupStorageSpec{
+		cr.Spec.StorageName: stg,
+	}
+
+	return &psmdbv1.PerconaServerMongoDB{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       verificationClusterName(cr),
+			Namespace:  cr.Namespace,
+			Finalizers: []string{naming.FinalizerDeletePVC},
+		},
+		Spec: *spec,
+	}
+}
+
+// newVerificationUsersSecret returns the users secret of the verification cluster
+// with the credentials from the given users secret of the cluster.
+func newVerificationUsersSecret(vcluster *psmdbv1.PerconaServerMongoDB, users *corev1.Secret) *corev1.Secret {
+	data := make(map[string][]byte, len(users.Data))
+	for k, v := range users.Data {
+		data[k] = v
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      vcluster.Spec.Secrets.Users,
+			Namespace: vcluster.Namespace,
+		},
+		Data: data,
+		Type: corev1.SecretTypeOpaque,
+	}
+}
+
+func newVerificationRestore(cr *psmdbv1.PerconaServerMongoDBBackup, clusterName string) *psmdbv1.PerconaServerMongoDBRestore {
+	return &psmdbv1.PerconaServerMongoDBRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName,
+			Namespace: cr.Namespace,
+		},
+		Spec: psmdbv1.PerconaServerMongoDBRestoreSpec{
+			ClusterName: clusterName,
+			BackupName:  cr.Name,
+		},
+	}
+}
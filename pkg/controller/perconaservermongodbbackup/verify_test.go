@@ -0,0 +1,182 @@
+package perconaservermongodbbackup
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/mongo"
+	fakeMongo "github.com/percona/percona-server-mongodb-operator/pkg/psmdb/mongo/fake"
+)
+
+type verifyMongoClient struct {
+	mongo.Client
+
+	collections map[string][]string
+	invalid     map[string]bool
+}
+
+func (c *verifyMongoClient) ListDBs(ctx context.Context) (mongo.DBList, error) {
+	list := mongo.DBList{}
+	for _, db := range []string{"admin", "config", "local", "app"} {
+		list.DBs = append(list.DBs, struct {
+			Name string `bson:"name" json:"name"`
+		}{Name: db})
+	}
+	return list, nil
+}
+
+func (c *verifyMongoClient) ListCollections(ctx context.Context, db string) ([]string, error) {
+	return c.collections[db], nil
+}
+
+func (c *verifyMongoClient) ValidateCollection(ctx context.Context, db, coll string) (*mongo.ValidateResp, error) {
+	if c.invalid[db+"."+coll] {
+		return &mongo.ValidateResp{Valid: false, Errors: []string{"corrupted"}}, nil
+	}
+	return &mongo.ValidateResp{Valid: true}, nil
+}
+
+func (c *verifyMongoClient) DBHash(ctx context.Context, db string) (*mongo.DBHashResp, error) {
+	return &mongo.DBHashResp{MD5: db + "-md5"}, nil
+}
+
+func TestVerifyData(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		invalid map[string]bool
+		ok      bool
+		summary string
+	}{
+		"valid": {
+			ok:      true,
+			summary: "3 collections in 2 databases passed validation",
+		},
+		"invalid collection": {
+			invalid: map[string]bool{"app.orders": true},
+			ok:      false,
+			summary: "1 of 3 collections failed validation: app.orders (corrupted)",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cli := &verifyMongoClient{
+				Client: fakeMongo.NewClient(),
+				collections: map[string][]string{
+					"admin":  {"system.users"},
+					"config": {"system.sessions"},
+					"app":    {"users", "orders"},
+				},
+				invalid: tt.invalid,
+			}
+
+			ok, summary, hashes, err := verifyData(ctx, cli)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok {
+				t.Errorf("expected ok %t, got %t", tt.ok, ok)
+			}
+			if summary != tt.summary {
+				t.Errorf("expected summary %q, got %q", tt.summary, summary)
+			}
+			if len(hashes) != 2 || hashes["app"] != "app-md5" {
+				t.Errorf("unexpected hashes: %v", hashes)
+			}
+		})
+	}
+}
+
+func TestNewVerificationCluster(t *testing.T) {
+	cluster := &psmdbv1.PerconaServerMongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "ns"},
+		Spec: psmdbv1.PerconaServerMongoDBSpec{
+			CRVersion: "1.18.0",
+			Replsets: []*psmdbv1.ReplsetSpec{
+				{
+					Name:    "rs0",
+					Size:    3,
+					Arbiter: psmdbv1.Arbiter{Enabled: true, Size: 1},
+				},
+			},
+			Secrets: &psmdbv1.SecretsSpec{
+				Users:         "cluster-secrets",
+				SSL:           "cluster-ssl",
+				EncryptionKey: "cluster-encryption-key",
+			},
+			Backup: psmdbv1.BackupSpec{
+				Enabled: true,
+				Tasks:   []psmdbv1.BackupTaskSpec{{Name: "daily"}},
+				PITR:    psmdbv1.PITRSpec{Enabled: true},
+				Storages: map[string]psmdbv1.BackupStorageSpec{
+					"s3-main":  {Main: true, Type: psmdbv1.BackupStorageS3},
+					"s3-other": {Type: psmdbv1.BackupStorageS3},
+				},
+			},
+		},
+	}
+	bcp := &psmdbv1.PerconaServerMongoDBBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns", UID: "0d8b1f6c-5c0e-4ad8-a26e-0a1d8a9e0b1c"},
+		Spec:       psmdbv1.PerconaServerMongoDBBackupSpec{StorageName: "s3-other"},
+	}
+
+	vcluster := newVerificationCluster(cluster, bcp)
+
+	if vcluster.Name != "verify-0d8b1f6c" {
+		t.Errorf("unexpected name %s", vcluster.Name)
+	}
+	if len(vcluster.Spec.Replsets) != 1 || vcluster.Spec.Replsets[0].Size != 1 || vcluster.Spec.Replsets[0].Arbiter.Enabled {
+		t.Errorf("expected single-node replset, got %+v", vcluster.Spec.Replsets)
+	}
+	if !vcluster.Spec.Unsafe.ReplsetSize {
+		t.Error("expected unsafe replset size")
+	}
+	if len(vcluster.Spec.Backup.Storages) != 1 || vcluster.Spec.Backup.PITR.Enabled || len(vcluster.Spec.Backup.Tasks) != 0 {
+		t.Errorf("unexpected backup spec: %+v", vcluster.Spec.Backup)
+	}
+	if _, ok := vcluster.Spec.Backup.Storages["s3-other"]; !ok {
+		t.Error("expected backup storage in the verification cluster")
+	}
+	if vcluster.Spec.Secrets.SSL != "" || vcluster.Spec.Secrets.Users != "verify-0d8b1f6c-secrets" || vcluster.Spec.Secrets.EncryptionKey != "cluster-encryption-key" {
+		t.Errorf("unexpected secrets: %+v", vcluster.Spec.Secrets)
+	}
+	if cluster.Spec.Replsets[0].Size != 3 || len(cluster.Spec.Backup.Storages) != 2 || cluster.Spec.Secrets.Users != "cluster-secrets" {
+		t.Error("source cluster spec is modified")
+	}
+}
+
+func TestNewVerificationUsersSecret(t *testing.T) {
+	vcluster := &psmdbv1.PerconaServerMongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "verify-0d8b1f6c", Namespace: "ns"},
+		Spec: psmdbv1.PerconaServerMongoDBSpec{
+			Secrets: &psmdbv1.SecretsSpec{Users: "verify-0d8b1f6c-secrets"},
+		},
+	}
+	users := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "internal-cluster-users", Namespace: "ns"},
+		Data: map[string][]byte{
+			psmdbv1.EnvMongoDBDatabaseAdminUser:     []byte("databaseAdmin"),
+			psmdbv1.EnvMongoDBDatabaseAdminPassword: []byte("password"),
+		},
+	}
+
+	secret := newVerificationUsersSecret(vcluster, users)
+
+	if secret.Name != "verify-0d8b1f6c-secrets" || secret.Namespace != "ns" {
+		t.Errorf("unexpected secret %s/%s", secret.Namespace, secret.Name)
+	}
+	if !reflect.DeepEqual(secret.Data, users.Data) {
+		t.Errorf("expected data %v, got %v", users.Data, secret.Data)
+	}
+
+	secret.Data[psmdbv1.EnvMongoDBDatabaseAdminPassword] = []byte("changed")
+	if string(users.Data[psmdbv1.EnvMongoDBDatabaseAdminPassword]) != "password" {
+		t.Error("cluster users secret is modified")
+	}
+}
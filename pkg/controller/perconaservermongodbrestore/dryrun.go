@@ -0,0 +1,423 @@
+package perconaservermongodbrestore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	pbmErrors "github.com/percona/percona-backup-mongodb/pbm/errors"
+	"github.com/percona/percona-backup-mongodb/pbm/oplog"
+	pbmVersion "github.com/percona/percona-backup-mongodb/pbm/version"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+)
+
+// reconcileDryRun runs pre-flight checks of the restore and reports them in status.dryRun.
+// The cluster is not changed: nothing is stopped, scaled or sent to PBM.
+func (r *ReconcilePerconaServerMongoDBRestore) reconcileDryRun(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore, cluster *psmdbv1.PerconaServerMongoDB) (psmdbv1.PerconaServerMongoDBRestoreStatus, error) {
+	log := logf.FromContext(ctx)
+	status := cr.Status
+
+	pbmc, err := r.newPBMFunc(ctx, r.client, cluster)
+	if err != nil {
+		log.Info("Waiting for pbm-agent.")
+		return status, errWaitingPBM
+	}
+	defer pbmc.Close(ctx)
+
+	checks := r.restoreChecks(ctx, cr, cluster, pbmc)
+
+	var failed []string
+	for _, c := range checks {
+		if c.Result == psmdbv1.RestoreCheckFailed {
+			failed = append(failed, string(c.Name))
+		}
+	}
+
+	now := metav1.Now()
+	status.DryRun = &psmdbv1.RestoreDryRunStatus{
+		Passed:    len(failed) == 0,
+		Checks:    checks,
+		CheckedAt: &now,
+	}
+	status.CompletedAt = &now
+
+	if len(failed) > 0 {
+		status.State = psmdbv1.RestoreStateRejected
+		status.Error = fmt.Sprintf("dry run failed checks: %s", strings.Join(failed, ", "))
+		log.Info("Restore dry run failed", "checks", failed)
+		return status, nil
+	}
+
+	status.State = psmdbv1.RestoreStateReady
+	log.Info("Restore dry run passed")
+
+	return status, nil
+}
+
+// restoreChecks checks that the backup can be restored to the cluster.
+// Checks that depend on an unavailable backup are skipped.
+func (r *ReconcilePerconaServerMongoDBRestore) restoreChecks(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore, cluster *psmdbv1.PerconaServerMongoDB, pbmc backup.PBM) []psmdbv1.RestoreCheck {
+	skipAll := func(first psmdbv1.RestoreCheck, msg string) []psmdbv1.RestoreCheck {
+		return []psmdbv1.RestoreCheck{
+			first,
+			checkSkipped(psmdbv1.RestoreCheckStorage, msg),
+			checkSkipped(psmdbv1.RestoreCheckVersion, msg),
+			checkSkipped(psmdbv1.RestoreCheckTopology, msg),
+			checkSkipped(psmdbv1.RestoreCheckPITR, msg),
+			checkSkipped(psmdbv1.RestoreCheckVolumeSize, msg),
+		}
+	}
+
+	bcp, err := r.getBackup(ctx, cr)
+	if err != nil {
+		return skipAll(checkFailed(psmdbv1.RestoreCheckBackup, errors.Wrap(err, "get backup")), "backup is not found")
+	}
+	if bcp.Status.State != psmdbv1.BackupStateReady {
+		err := errors.Errorf("backup is in %s state", bcp.Status.State)
+		return skipAll(checkFailed(psmdbv1.RestoreCheckBackup, err), "backup is not ready")
+	}
+
+	meta, err := pbmc.GetBackupMeta(ctx, bcp.Status.PBMname)
+	if err != nil && !errors.Is(err, pbmErrors.ErrNotFound) {
+		return skipAll(checkFailed(psmdbv1.RestoreCheckBackup, errors.Wrap(err, "get backup metadata")), "backup metadata is not available")
+	}
+
+	versionCheck := checkVersion(meta, cluster.Status.MongoVersion, "")
+	if meta != nil && meta.Type == defs.LogicalBackup && meta.FCV != "" {
+		fcv, err := pbmc.GetFCV(ctx)
+		if err != nil {
+			versionCheck = checkFailed(psmdbv1.RestoreCheckVersion, errors.Wrap(err, "get feature compatibility version"))
+		} else {
+			versionCheck = checkVersion(meta, cluster.Status.MongoVersion, fcv)
+		}
+	}
+
+	backupReplsets := bcp.Status.ReplsetNames
+	if meta != nil && len(meta.Replsets) > 0 {
+		backupReplsets = make([]string, 0, len(meta.Replsets))
+		for _, rs := range meta.Replsets {
+			backupReplsets = append(backupReplsets, rs.Name)
+		}
+	}
+
+	return []psmdbv1.RestoreCheck{
+		checkBackup(cr, bcp, meta),
+		r.checkStorage(ctx, cr, cluster, bcp, pbmc),
+		versionCheck,
+		checkTopology(bcp.Status.Type, cr.Spec.ReplsetRemapping, backupReplsets, cluster),
+		checkPITR(ctx, cr, cluster, bcp, meta, pbmc),
+		r.checkVolumeSize(ctx, cr, cluster, bcp, meta, pbmc),
+	}
+}
+
+func checkPassed(name psmdbv1.RestoreCheckName, format string, args ...any) psmdbv1.RestoreCheck {
+	return psmdbv1.RestoreCheck{Name: name, Result: psmdbv1.RestoreCheckPassed, Message: fmt.Sprintf(format, args...)}
+}
+
+func checkFailed(name psmdbv1.RestoreCheckName, err error) psmdbv1.RestoreCheck {
+	return psmdbv1.RestoreCheck{Name: name, Result: psmdbv1.RestoreCheckFailed, Message: err.Error()}
+}
+
+func checkSkipped(name psmdbv1.RestoreCheckName, msg string) psmdbv1.RestoreCheck {
+	return psmdbv1.RestoreCheck{Name: name, Result: psmdbv1.RestoreCheckSkipped, Message: msg}
+}
+
+func checkBackup(cr *psmdbv1.PerconaServerMongoDBRestore, bcp *psmdbv1.PerconaServerMongoDBBackup, meta *backup.BackupMeta) psmdbv1.RestoreCheck {
+	if bcp.Status.Type != defs.LogicalBackup && cr.Spec.Selective != nil {
+		return checkFailed(psmdbv1.RestoreCheckBackup, errors.New("`.spec.selective` field is supported only for logical backups"))
+	}
+
+	for _, ns := range cr.Spec.Selective.GetNamespaces() {
+		if !bcp.Status.ContainsNamespace(ns) {
+			return checkFailed(psmdbv1.RestoreCheckBackup, errors.Errorf("namespace %s is not in the backup, backup contains only %v", ns, bcp.Status.Namespaces))
+		}
+	}
+
+	// backups from backupSource are not known by PBM until resync
+	if meta == nil {
+		return checkPassed(psmdbv1.RestoreCheckBackup, "backup %s is ready, its metadata is read from the storage on restore", bcp.Status.PBMname)
+	}
+
+	if meta.Status != defs.StatusDone {
+		return checkFailed(psmdbv1.RestoreCheckBackup, errors.Errorf("backup %s is in %s state in PBM", meta.Name, meta.Status))
+	}
+
+	return checkPassed(psmdbv1.RestoreCheckBackup, "backup %s is complete", meta.Name)
+}
+
+func (r *ReconcilePerconaServerMongoDBRestore) checkStorage(
+	ctx context.Context,
+	cr *psmdbv1.PerconaServerMongoDBRestore,
+	cluster *psmdbv1.PerconaServerMongoDB,
+	bcp *psmdbv1.PerconaServerMongoDBBackup,
+	pbmc backup.PBM,
+) psmdbv1.RestoreCheck {
+	stg, err := r.getStorage(cr, cluster, bcp.Spec.StorageName)
+	if err != nil {
+		return checkFailed(psmdbv1.RestoreCheckStorage, errors.Wrap(err, "get storage"))
+	}
+
+	if stg.Type == psmdbv1.BackupStorageFilesystem {
+		return checkSkipped(psmdbv1.RestoreCheckStorage, "filesystem storage is available only in database pods")
+	}
+
+	cfg, err := backup.GetPBMConfig(ctx, r.client, cluster, stg)
+	if err != nil {
+		return checkFailed(psmdbv1.RestoreCheckStorage, errors.Wrap(err, "get pbm config"))
+	}
+
+	if err := pbmc.ValidateBackup(ctx, bcp, cfg); err != nil {
+		return checkFailed(psmdbv1.RestoreCheckStorage, errors.Wrap(err, "check backup files"))
+	}
+
+	return checkPassed(psmdbv1.RestoreCheckStorage, "backup files are in %s storage", stg.Type)
+}
+
+// checkVersion checks MongoDB compatibility the same way pbm-agents do on restore:
+// logical backups need the same FCV, physical backups need the same major version.
+func checkVersion(meta *backup.BackupMeta, mongoVersion, fcv string) psmdbv1.RestoreCheck {
+	if meta == nil {
+		return checkSkipped(psmdbv1.RestoreCheckVersion, "backup metadata is not available")
+	}
+
+	if meta.PBMVersion != "" && !pbmVersion.CompatibleWith(meta.PBMVersion, pbmVersion.BreakingChangesMap[meta.Type]) {
+		return checkFailed(psmdbv1.RestoreCheckVersion, errors.Errorf("backup PBM version %s is incompatible with PBM %s", meta.PBMVersion, pbmVersion.Current().Version))
+	}
+
+	if meta.Type == defs.LogicalBackup && meta.FCV != "" && fcv != "" {
+		if meta.FCV != fcv {
+			return checkFailed(psmdbv1.RestoreCheckVersion, errors.Errorf("backup FCV %s doesn't match cluster FCV %s", meta.FCV, fcv))
+		}
+		return checkPassed(psmdbv1.RestoreCheckVersion, "backup FCV %s matches the cluster", meta.FCV)
+	}
+
+	if mongoVersion == "" || meta.MongoVersion == "" {
+		return checkSkipped(psmdbv1.RestoreCheckVersion, "MongoDB version is unknown")
+	}
+
+	bcpVersion, err := majorMinor(meta.MongoVersion)
+	if err != nil {
+		return checkFailed(psmdbv1.RestoreCheckVersion, errors.Wrap(err, "parse backup MongoDB version"))
+	}
+	clusterVersion, err := majorMinor(mongoVersion)
+	if err != nil {
+		return checkFailed(psmdbv1.RestoreCheckVersion, errors.Wrap(err, "parse cluster MongoDB version"))
+	}
+
+	if bcpVersion != clusterVersion {
+		return checkFailed(psmdbv1.RestoreCheckVersion, errors.Errorf("backup MongoDB version %s is incompatible with cluster MongoDB version %s", meta.MongoVersion, mongoVersion))
+	}
+
+	return checkPassed(psmdbv1.RestoreCheckVersion, "backup MongoDB version %s is compatible with %s", meta.MongoVersion, mongoVersion)
+}
+
+func majorMinor(version string) (string, error) {
+	ver, err := v.NewVersion(version)
+	if err != nil {
+		return "", err
+	}
+
+	s := ver.Segments()
+	if len(s) == 1 {
+		s = append(s, 0)
+	}
+
+	return fmt.Sprintf("%d.%d", s[0], s[1]), nil
+}
+
+// checkTopology checks that every replset of the backup has a target replset in the cluster.
+// Physical restores also need every replset of the cluster to be restored.
+func checkTopology(bcpType defs.BackupType, rsMap map[string]string, backupReplsets []string, cluster *psmdbv1.PerconaServerMongoDB) psmdbv1.RestoreCheck {
+	if len(backupReplsets) == 0 {
+		return checkSkipped(psmdbv1.RestoreCheckTopology, "replsets of the backup are unknown")
+	}
+
+	if err := validateReplsetRemapping(rsMap, backupReplsets, cluster); err != nil {
+		return checkFailed(psmdbv1.RestoreCheckTopology, err)
+	}
+
+	if bcpType == defs.PhysicalBackup || bcpType == defs.IncrementalBackup {
+		restored := make(map[string]struct{}, len(backupReplsets))
+		for _, rs := range backupReplsets {
+			to, ok := rsMap[rs]
+			if !ok {
+				to = rs
+			}
+			restored[to] = struct{}{}
+		}
+
+		for name := range clusterReplsets(cluster) {
+			if _, ok := restored[name]; !ok {
+				return checkFailed(psmdbv1.RestoreCheckTopology, errors.Errorf("replset %s of cluster %s is not in the backup", name, cluster.Name))
+			}
+		}
+	}
+
+	return checkPassed(psmdbv1.RestoreCheckTopology, "backup replsets %v match cluster %s", backupReplsets, cluster.Name)
+}
+
+func checkPITR(
+	ctx context.Context,
+	cr *psmdbv1.PerconaServerMongoDBRestore,
+	cluster *psmdbv1.PerconaServerMongoDB,
+	bcp *psmdbv1.PerconaServerMongoDBBackup,
+	meta *backup.BackupMeta,
+	pbmc backup.PBM,
+) psmdbv1.RestoreCheck {
+	if cr.Spec.PITR == nil {
+		return checkSkipped(psmdbv1.RestoreCheckPITR, "point-in-time recovery is not requested")
+	}
+
+	if cluster.Spec.Backup.StorageProfile(bcp.Spec.StorageName) != "" {
+		return checkFailed(psmdbv1.RestoreCheckPITR, errors.Errorf("point-in-time recovery is supported only for backups in the main storage, backup is in storage %s", bcp.Spec.StorageName))
+	}
+
+	target, err := resolvePITRTarget(ctx, pbmc, cr.Spec.PITR)
+	if err != nil {
+		return checkFailed(psmdbv1.RestoreCheckPITR, errors.Wrap(err, "resolve target"))
+	}
+
+	if meta == nil {
+		return checkPassed(psmdbv1.RestoreCheckPITR, "oplog contains target %s", psmdbv1.PITRTarget(target))
+	}
+
+	if target.Before(meta.LastWriteTS) {
+		return checkFailed(psmdbv1.RestoreCheckPITR, errors.Errorf("target %s is before the backup last write %s", psmdbv1.PITRTarget(target), psmdbv1.PITRTarget(meta.LastWriteTS)))
+	}
+
+	for _, rs := range meta.Replsets {
+		chunks, err := pbmc.PITRGetChunksSlice(ctx, rs.Name, meta.LastWriteTS, target)
+		if err != nil {
+			return checkFailed(psmdbv1.RestoreCheckPITR, errors.Wrapf(err, "get oplog chunks of replset %s", rs.Name))
+		}
+
+		if err := checkOplogCoverage(chunks, meta.LastWriteTS, target); err != nil {
+			return checkFailed(psmdbv1.RestoreCheckPITR, errors.Wrapf(err, "replset %s", rs.Name))
+		}
+	}
+
+	return checkPassed(psmdbv1.RestoreCheckPITR, "oplog covers %s to %s", psmdbv1.PITRTarget(meta.LastWriteTS), psmdbv1.PITRTarget(target))
+}
+
+// checkOplogCoverage checks that chunks cover the oplog between from and to without gaps.
+func checkOplogCoverage(chunks []oplog.OplogChunk, from, to primitive.Timestamp) error {
+	if len(chunks) == 0 {
+		return errors.New("no oplog chunks found")
+	}
+
+	last := from
+	for _, c := range chunks {
+		if last.Before(c.StartTS) {
+			return errors.Errorf("oplog has a gap from %s to %s", psmdbv1.PITRTarget(last), psmdbv1.PITRTarget(c.StartTS))
+		}
+		last = c.EndTS
+	}
+
+	if last.Before(to) {
+		return errors.Errorf("oplog ends at %s", psmdbv1.PITRTarget(last))
+	}
+
+	return nil
+}
+
+// checkVolumeSize estimates if the data of a physical backup fits the requested size
+// of the replset volumes. The data of every replset is replaced by the restore,
+// so the size of its data files is compared with the size of the volume.
+// The sizes of the data files are read from the lists of files in the storage.
+// Logical backups record only the compressed size of the whole backup,
+// which doesn't tell how much space the restored data of each replset needs.
+func (r *ReconcilePerconaServerMongoDBRestore) checkVolumeSize(
+	ctx context.Context,
+	cr *psmdbv1.PerconaServerMongoDBRestore,
+	cluster *psmdbv1.PerconaServerMongoDB,
+	bcp *psmdbv1.PerconaServerMongoDBBackup,
+	meta *backup.BackupMeta,
+	pbmc backup.PBM,
+) psmdbv1.RestoreCheck {
+	if meta == nil {
+		return checkSkipped(psmdbv1.RestoreCheckVolumeSize, "backup metadata is not available")
+	}
+	if meta.Type == defs.LogicalBackup {
+		return checkSkipped(psmdbv1.RestoreCheckVolumeSize, "size of the restored data is unknown for logical backups")
+	}
+
+	stg, err := r.getStorage(cr, cluster, bcp.Spec.StorageName)
+	if err != nil {
+		return checkFailed(psmdbv1.RestoreCheckVolumeSize, errors.Wrap(err, "get storage"))
+	}
+	if stg.Type == psmdbv1.BackupStorageFilesystem {
+		return checkSkipped(psmdbv1.RestoreCheckVolumeSize, "filesystem storage is available only in database pods")
+	}
+
+	cfg, err := backup.GetPBMConfig(ctx, r.client, cluster, stg)
+	if err != nil {
+		return checkFailed(psmdbv1.RestoreCheckVolumeSize, errors.Wrap(err, "get pbm config"))
+	}
+
+	sizes, err := pbmc.GetReplsetDataSizes(ctx, &cfg.Storage, meta.Name)
+	if err != nil {
+		return checkFailed(psmdbv1.RestoreCheckVolumeSize, errors.Wrap(err, "get backup data size"))
+	}
+
+	return checkReplsetDataSizes(meta, sizes, cr.Spec.ReplsetRemapping, cluster)
+}
+
+// checkReplsetDataSizes compares the sizes of the restored data of the replsets
+// with the requested sizes of their volumes.
+func checkReplsetDataSizes(meta *backup.BackupMeta, sizes map[string]int64, rsMap map[string]string, cluster *psmdbv1.PerconaServerMongoDB) psmdbv1.RestoreCheck {
+	replsets := clusterReplsets(cluster)
+
+	checked := 0
+	for _, rs := range meta.Replsets {
+		to := targetReplset(rsMap, rs.Name)
+		size, ok := replsetVolumeSize(replsets[to])
+		if !ok {
+			continue
+		}
+
+		dataSize, ok := sizes[rs.Name]
+		if !ok {
+			return checkSkipped(psmdbv1.RestoreCheckVolumeSize, fmt.Sprintf("size of the data of replset %s is unknown", rs.Name))
+		}
+
+		required := resource.NewQuantity(dataSize, resource.BinarySI)
+		if size.Cmp(*required) < 0 {
+			return checkFailed(psmdbv1.RestoreCheckVolumeSize, errors.Errorf("replset %s needs an estimated %s, its volume requests %s", to, required, &size))
+		}
+		checked++
+	}
+
+	if checked == 0 {
+		return checkSkipped(psmdbv1.RestoreCheckVolumeSize, "replsets don't use persistent volumes")
+	}
+
+	return checkPassed(psmdbv1.RestoreCheckVolumeSize, "estimated backup data size fits requested replset volume sizes, free space is not measured")
+}
+
+func targetReplset(rsMap map[string]string, name string) string {
+	if to, ok := rsMap[name]; ok {
+		return to
+	}
+	return name
+}
+
+// replsetVolumeSize returns the requested size of the replset data volume.
+func replsetVolumeSize(rs *psmdbv1.ReplsetSpec) (resource.Quantity, bool) {
+	if rs == nil || rs.VolumeSpec == nil || rs.VolumeSpec.PersistentVolumeClaim.PersistentVolumeClaimSpec == nil {
+		return resource.Quantity{}, false
+	}
+
+	size, ok := rs.VolumeSpec.PersistentVolumeClaim.Resources.Requests[corev1.ResourceStorage]
+	return size, ok
+}
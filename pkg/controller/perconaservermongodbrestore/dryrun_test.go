@@ -0,0 +1,293 @@
+package perconaservermongodbrestore
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pbmBackup "github.com/percona/percona-backup-mongodb/pbm/backup"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/oplog"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+)
+
+func TestReconcileDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	ns := "dry-run"
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3-secret", Namespace: ns},
+	}
+	cluster := readDefaultCluster(t, "cluster", ns)
+	cluster.Spec.Sharding.ConfigsvrReplSet.Name = psmdbv1.ConfigReplSetName
+	cluster.Spec.Backup.Storages = map[string]psmdbv1.BackupStorageSpec{
+		"s3": {
+			Type: psmdbv1.BackupStorageS3,
+			S3: psmdbv1.BackupStorageS3Spec{
+				Bucket:            "bucket",
+				Region:            "region",
+				CredentialsSecret: secret.Name,
+			},
+		},
+	}
+	bcp := readDefaultBackup(t, "backup", ns)
+	bcp.Spec.ClusterName = cluster.Name
+	bcp.Spec.StorageName = "s3"
+	bcp.Status.State = psmdbv1.BackupStateReady
+	bcp.Status.Type = defs.LogicalBackup
+	bcp.Status.ReplsetNames = []string{"cfg", "rs0"}
+
+	cr := readDefaultRestore(t, "restore", ns)
+	cr.Spec.ClusterName = cluster.Name
+	cr.Spec.BackupName = bcp.Name
+	cr.Spec.DryRun = true
+
+	t.Run("passed", func(t *testing.T) {
+		r := fakeReconciler(cr.DeepCopy(), cluster.DeepCopy(), bcp.DeepCopy(), secret.DeepCopy())
+
+		status, err := r.reconcileDryRun(ctx, cr.DeepCopy(), cluster.DeepCopy())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.State != psmdbv1.RestoreStateReady || !status.DryRun.Passed {
+			t.Fatalf("expected passed dry run, got state %s: %v", status.State, status.DryRun.Checks)
+		}
+		if len(status.DryRun.Checks) != 6 {
+			t.Errorf("expected 6 checks, got %d", len(status.DryRun.Checks))
+		}
+	})
+
+	t.Run("no backup", func(t *testing.T) {
+		r := fakeReconciler(cr.DeepCopy(), cluster.DeepCopy(), secret.DeepCopy())
+
+		status, err := r.reconcileDryRun(ctx, cr.DeepCopy(), cluster.DeepCopy())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.State != psmdbv1.RestoreStateRejected || status.DryRun.Passed {
+			t.Fatalf("expected failed dry run, got state %s", status.State)
+		}
+		if status.Error != "dry run failed checks: backup" {
+			t.Errorf("unexpected error: %s", status.Error)
+		}
+		for _, c := range status.DryRun.Checks[1:] {
+			if c.Result != psmdbv1.RestoreCheckSkipped {
+				t.Errorf("expected %s check to be skipped, got %s", c.Name, c.Result)
+			}
+		}
+	})
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := map[string]struct {
+		meta         *backup.BackupMeta
+		mongoVersion string
+		fcv          string
+		expected     psmdbv1.RestoreCheckResult
+	}{
+		"no metadata": {
+			expected: psmdbv1.RestoreCheckSkipped,
+		},
+		"logical same fcv": {
+			meta:         &backup.BackupMeta{Type: defs.LogicalBackup, MongoVersion: "6.0.15", FCV: "6.0"},
+			mongoVersion: "7.0.12",
+			fcv:          "6.0",
+			expected:     psmdbv1.RestoreCheckPassed,
+		},
+		"logical different fcv": {
+			meta:         &backup.BackupMeta{Type: defs.LogicalBackup, MongoVersion: "6.0.15", FCV: "6.0"},
+			mongoVersion: "7.0.12",
+			fcv:          "7.0",
+			expected:     psmdbv1.RestoreCheckFailed,
+		},
+		"physical same version": {
+			meta:         &backup.BackupMeta{Type: defs.PhysicalBackup, MongoVersion: "7.0.8-5"},
+			mongoVersion: "7.0.12-7",
+			expected:     psmdbv1.RestoreCheckPassed,
+		},
+		"physical different version": {
+			meta:         &backup.BackupMeta{Type: defs.PhysicalBackup, MongoVersion: "6.0.15-12"},
+			mongoVersion: "7.0.12-7",
+			expected:     psmdbv1.RestoreCheckFailed,
+		},
+		"unknown cluster version": {
+			meta:     &backup.BackupMeta{Type: defs.PhysicalBackup, MongoVersion: "6.0.15-12"},
+			expected: psmdbv1.RestoreCheckSkipped,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := checkVersion(tt.meta, tt.mongoVersion, tt.fcv)
+			if c.Result != tt.expected {
+				t.Errorf("expected %s, got %s: %s", tt.expected, c.Result, c.Message)
+			}
+		})
+	}
+}
+
+func TestCheckTopology(t *testing.T) {
+	cluster := readDefaultCluster(t, "cluster", "topology")
+	cluster.Spec.Sharding.ConfigsvrReplSet.Name = psmdbv1.ConfigReplSetName
+
+	tests := map[string]struct {
+		bcpType        defs.BackupType
+		rsMap          map[string]string
+		backupReplsets []string
+		expected       psmdbv1.RestoreCheckResult
+	}{
+		"unknown replsets": {
+			bcpType:  defs.LogicalBackup,
+			expected: psmdbv1.RestoreCheckSkipped,
+		},
+		"same replsets": {
+			bcpType:        defs.PhysicalBackup,
+			backupReplsets: []string{"cfg", "rs0"},
+			expected:       psmdbv1.RestoreCheckPassed,
+		},
+		"remapped replsets": {
+			bcpType:        defs.PhysicalBackup,
+			rsMap:          map[string]string{"prod-cfg": "cfg", "prod-rs0": "rs0"},
+			backupReplsets: []string{"prod-cfg", "prod-rs0"},
+			expected:       psmdbv1.RestoreCheckPassed,
+		},
+		"backup replset not in cluster": {
+			bcpType:        defs.LogicalBackup,
+			backupReplsets: []string{"cfg", "rs0", "rs1"},
+			expected:       psmdbv1.RestoreCheckFailed,
+		},
+		"logical backup of a part of the cluster": {
+			bcpType:        defs.LogicalBackup,
+			backupReplsets: []string{"rs0"},
+			expected:       psmdbv1.RestoreCheckPassed,
+		},
+		"physical backup of a part of the cluster": {
+			bcpType:        defs.PhysicalBackup,
+			backupReplsets: []string{"rs0"},
+			expected:       psmdbv1.RestoreCheckFailed,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := checkTopology(tt.bcpType, tt.rsMap, tt.backupReplsets, cluster)
+			if c.Result != tt.expected {
+				t.Errorf("expected %s, got %s: %s", tt.expected, c.Result, c.Message)
+			}
+		})
+	}
+}
+
+func TestCheckOplogCoverage(t *testing.T) {
+	chunk := func(start, end uint32) oplog.OplogChunk {
+		return oplog.OplogChunk{StartTS: primitive.Timestamp{T: start}, EndTS: primitive.Timestamp{T: end}}
+	}
+
+	tests := map[string]struct {
+		chunks  []oplog.OplogChunk
+		to      uint32
+		wantErr bool
+	}{
+		"no chunks": {
+			to:      200,
+			wantErr: true,
+		},
+		"contiguous": {
+			chunks: []oplog.OplogChunk{chunk(90, 150), chunk(150, 250)},
+			to:     200,
+		},
+		"gap": {
+			chunks:  []oplog.OplogChunk{chunk(90, 150), chunk(160, 250)},
+			to:      200,
+			wantErr: true,
+		},
+		"ends before target": {
+			chunks:  []oplog.OplogChunk{chunk(90, 150)},
+			to:      200,
+			wantErr: true,
+		},
+		"starts after backup": {
+			chunks:  []oplog.OplogChunk{chunk(110, 250)},
+			to:      200,
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkOplogCoverage(tt.chunks, primitive.Timestamp{T: 100}, primitive.Timestamp{T: tt.to})
+			if tt.wantErr && err == nil {
+				t.Error("expected error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckVolumeSize(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := readDefaultCluster(t, "cluster", "disk-space")
+	cluster.Spec.Sharding.ConfigsvrReplSet.Name = psmdbv1.ConfigReplSetName
+
+	gib := int64(1 << 30)
+	physical := &backup.BackupMeta{
+		Type:     defs.PhysicalBackup,
+		Replsets: []pbmBackup.BackupReplset{{Name: "cfg"}, {Name: "rs0"}},
+	}
+
+	tests := map[string]struct {
+		meta     *backup.BackupMeta
+		sizes    map[string]int64
+		expected psmdbv1.RestoreCheckResult
+	}{
+		"no metadata": {
+			expected: psmdbv1.RestoreCheckSkipped,
+		},
+		"logical": {
+			meta: &backup.BackupMeta{
+				Type:     defs.LogicalBackup,
+				Size:     7 * gib,
+				Replsets: []pbmBackup.BackupReplset{{Name: "cfg"}, {Name: "rs0"}},
+			},
+			expected: psmdbv1.RestoreCheckSkipped,
+		},
+		"physical fits": {
+			meta:     physical,
+			sizes:    map[string]int64{"cfg": gib, "rs0": 2 * gib},
+			expected: psmdbv1.RestoreCheckPassed,
+		},
+		"physical doesn't fit": {
+			meta:     physical,
+			sizes:    map[string]int64{"cfg": gib, "rs0": 4 * gib},
+			expected: psmdbv1.RestoreCheckFailed,
+		},
+		"unknown replset data size": {
+			meta:     physical,
+			sizes:    map[string]int64{"cfg": gib},
+			expected: psmdbv1.RestoreCheckSkipped,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var c psmdbv1.RestoreCheck
+			if tt.sizes == nil {
+				r := &ReconcilePerconaServerMongoDBRestore{}
+				c = r.checkVolumeSize(ctx, nil, cluster, nil, tt.meta, nil)
+			} else {
+				c = checkReplsetDataSizes(tt.meta, tt.sizes, nil, cluster)
+			}
+			if c.Result != tt.expected {
+				t.Errorf("expected %s, got %s: %s", tt.expected, c.Result, c.Message)
+			}
+		})
+	}
+}
@@ -62,9 +62,30 @@ func (r *ReconcilePerconaServerMongoDBRestore) reconcileLogicalRestore(ctx conte
 
 		// Disable PITR before restore
 		cluster.Spec.Backup.PITR.Enabled = false
-		err = pbmc.GetNSetConfig(ctx, r.client, cluster, storage)
-		if err != nil {
-			return status, errors.Wrap(err, "set pbm config")
+
+		// Backups from storages other than main are restored using config profiles,
+		// PBM main storage is kept to not break PITR oplog after restore.
+		if profile := cluster.Spec.Backup.StorageProfile(storageName); profile != "" {
+			_, mainStorage, _ := cluster.Spec.Backup.MainStorage()
+			err = pbmc.GetNSetConfig(ctx, r.client, cluster, mainStorage)
+			if err != nil {
+				return status, errors.Wrap(err, "set pbm config")
+			}
+
+			err = pbmc.GetNSetProfile(ctx, r.client, cluster, profile, storage)
+			if err != nil {
+				return status, errors.Wrap(err, "set pbm config profile")
+			}
+
+			err = pbmc.ResyncProfile(ctx, profile)
+			if err != nil {
+				return status, errors.Wrap(err, "resync pbm config profile")
+			}
+		} else {
+			err = pbmc.GetNSetConfig(ctx, r.client, cluster, storage)
+			if err != nil {
+				return status, errors.Wrap(err, "set pbm config")
+			}
 		}
 
 		isBlockedByPITR, err := pbmc.HasLocks(ctx, backup.IsPITRLock)
@@ -79,7 +100,11 @@ func (r *ReconcilePerconaServerMongoDBRestore) reconcileLogicalRestore(ctx conte
 		}
 
 		log.Info("Starting restore", "backup", backupName)
-		status.PBMname, err = runRestore(ctx, backupName, pbmc, cr.Spec.PITR, cr.Spec.Selective)
+		pbmName, target, err := runRestore(ctx, backupName, pbmc, cr.Spec.PITR, cr.Spec.Selective, cr.Spec.ReplsetRemapping)
+		status.PBMname = pbmName
+		if cr.Spec.PITR != nil {
+			status.PITRTarget = psmdbv1.PITRTarget(target)
+		}
 		status.State = psmdbv1.RestoreStateRequested
 		return status, err
 	}
@@ -129,76 +154,84 @@ func reEnablePITR(ctx context.Context, pbm backup.PBM, backup psmdbv1.BackupSpec
 	return
 }
 
-func runRestore(ctx context.Context, backup string, pbmc backup.PBM, pitr *psmdbv1.PITRestoreSpec, selective *psmdbv1.SelectiveRestoreOpts) (string, error) {
+// runRestore sends the restore command to PBM. It returns the PBM restore name
+// and the resolved point-in-time restore target, which is zero if pitr is nil.
+func runRestore(
+	ctx context.Context,
+	backup string,
+	pbmc backup.PBM,
+	pitr *psmdbv1.PITRestoreSpec,
+	selective *psmdbv1.SelectiveRestoreOpts,
+	rsMap map[string]string,
+) (string, primitive.Timestamp, error) {
 	log := logf.FromContext(ctx)
 	log.Info("Starting logical restore", "backup", backup)
 
 	cfg, err := pbmc.GetConfig(ctx)
 	if err != nil {
-		return "", errors.Wrap(err, "get PBM config")
+		return "", primitive.Timestamp{}, errors.Wrap(err, "get PBM config")
 	}
 
 	if cfg.Storage.Type != storage.Filesystem {
 		if err := pbmc.ResyncStorage(ctx, &cfg.Storage); err != nil {
-			return "", errors.Wrap(err, "resync storage")
+			return "", primitive.Timestamp{}, errors.Wrap(err, "resync storage")
 		}
 	}
 
-	var (
-		cmd   ctrl.Cmd
-		rName = time.Now().UTC().Format(time.RFC3339Nano)
-	)
-
-	switch {
-	case pitr == nil:
-		cmd = ctrl.Cmd{
-			Cmd: ctrl.CmdRestore,
-			Restore: &ctrl.RestoreCmd{
-				Name:          rName,
-				BackupName:    backup,
-				Namespaces:    selective.GetNamespaces(),
-				UsersAndRoles: selective.GetWithUsersAndRoles(),
-			},
+	var target primitive.Timestamp
+	if pitr != nil {
+		target, err = resolvePITRTarget(ctx, pbmc, pitr)
+		if err != nil {
+			return "", primitive.Timestamp{}, err
 		}
-	case pitr.Type == psmdbv1.PITRestoreTypeDate:
+	}
+
+	rName := time.Now().UTC().Format(time.RFC3339Nano)
+	cmd := ctrl.Cmd{
+		Cmd: ctrl.CmdRestore,
+		Restore: &ctrl.RestoreCmd{
+			Name:          rName,
+			BackupName:    backup,
+			OplogTS:       target,
+			Namespaces:    selective.GetNamespaces(),
+			UsersAndRoles: selective.GetWithUsersAndRoles(),
+			RSMap:         rsMap,
+		},
+	}
+
+	log.Info("Sending restore command", "restoreCmd", cmd.Restore)
+	if err = pbmc.SendCmd(ctx, cmd); err != nil {
+		return "", primitive.Timestamp{}, errors.Wrap(err, "send restore cmd")
+	}
+
+	return rName, target, nil
+}
+
+// resolvePITRTarget returns the oplog timestamp to restore to.
+func resolvePITRTarget(ctx context.Context, pbmc backup.PBM, pitr *psmdbv1.PITRestoreSpec) (primitive.Timestamp, error) {
+	switch pitr.Type {
+	case psmdbv1.PITRestoreTypeDate:
 		ts := pitr.Date.Unix()
 
 		if _, err := pbmc.GetPITRChunkContains(ctx, ts); err != nil {
-			return "", err
+			return primitive.Timestamp{}, err
 		}
 
-		cmd = ctrl.Cmd{
-			Cmd: ctrl.CmdRestore,
-			Restore: &ctrl.RestoreCmd{
-				Name:          rName,
-				BackupName:    backup,
-				OplogTS:       primitive.Timestamp{T: uint32(ts)},
-				Namespaces:    selective.GetNamespaces(),
-				UsersAndRoles: selective.GetWithUsersAndRoles(),
-			},
+		return primitive.Timestamp{T: uint32(ts)}, nil
+	case psmdbv1.PITRestoreTypeTimestamp:
+		if _, err := pbmc.GetPITRChunkContains(ctx, int64(pitr.Timestamp.T)); err != nil {
+			return primitive.Timestamp{}, err
 		}
-	case pitr.Type == psmdbv1.PITRestoreTypeLatest:
+
+		return pitr.Timestamp.BSON(), nil
+	case psmdbv1.PITRestoreTypeLatest:
 		tl, err := pbmc.GetLatestTimelinePITR(ctx)
 		if err != nil {
-			return "", err
+			return primitive.Timestamp{}, err
 		}
 
-		cmd = ctrl.Cmd{
-			Cmd: ctrl.CmdRestore,
-			Restore: &ctrl.RestoreCmd{
-				Name:          rName,
-				BackupName:    backup,
-				OplogTS:       primitive.Timestamp{T: tl.End},
-				Namespaces:    selective.GetNamespaces(),
-				UsersAndRoles: selective.GetWithUsersAndRoles(),
-			},
-		}
-	}
-
-	log.Info("Sending restore command", "restoreCmd", cmd.Restore)
-	if err = pbmc.SendCmd(ctx, cmd); err != nil {
-		return "", errors.Wrap(err, "send restore cmd")
+		return primitive.Timestamp{T: tl.End}, nil
 	}
 
-	return rName, nil
+	return primitive.Timestamp{}, errors.Errorf("undefined pitr restore type: %s", pitr.Type)
 }
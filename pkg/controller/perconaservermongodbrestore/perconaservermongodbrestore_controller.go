@@ -7,11 +7,13 @@ import (
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,7 +26,10 @@ import (
 
 	"github.com/percona/percona-server-mongodb-operator/clientcmd"
 	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/metrics"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/mongo"
 	"github.com/percona/percona-server-mongodb-operator/pkg/util"
 	"github.com/percona/percona-server-mongodb-operator/version"
 )
@@ -51,7 +56,11 @@ func newReconciler(mgr manager.Manager) (reconcile.Reconciler, error) {
 		client:     mgr.GetClient(),
 		scheme:     mgr.GetScheme(),
 		clientcmd:  cli,
+		recorder:   mgr.GetEventRecorderFor("psmdbrestore-controller"),
 		newPBMFunc: backup.NewPBM,
+
+		newStoragePBMFunc:       backup.NewStoragePBM,
+		newStandaloneClientFunc: psmdb.StandaloneClient,
 	}, nil
 }
 
@@ -81,10 +90,16 @@ type ReconcilePerconaServerMongoDBRestore struct {
 	client    client.Client
 	scheme    *runtime.Scheme
 	clientcmd *clientcmd.Client
+	recorder  record.EventRecorder
 
 	newPBMFunc backup.NewPBMFunc
+
+	newStoragePBMFunc       backup.NewPBMFunc
+	newStandaloneClientFunc newStandaloneClientFunc
 }
 
+type newStandaloneClientFunc func(ctx context.Context, k8sclient client.Client, cluster *psmdbv1.PerconaServerMongoDB, c psmdb.Credentials, host string, tlsEnabled bool) (mongo.Client, error)
+
 // Reconcile reads that state of the cluster for a PerconaServerMongoDBRestore object and makes changes based on the state read
 // and what is in the PerconaServerMongoDBRestore.Spec
 // Note:
@@ -117,14 +132,23 @@ func (r *ReconcilePerconaServerMongoDBRestore) Reconcile(ctx context.Context, re
 		if err != nil {
 			status.State = psmdbv1.RestoreStateError
 			status.Error = err.Error()
+			if status.Progress != nil {
+				status.Progress = progressWithPhase(status.Progress, psmdbv1.RestorePhaseFailed, metav1.Now())
+			}
 			log.Error(err, "failed to make restore", "restore", cr.Name, "backup", cr.Spec.BackupName)
 		}
-		if cr.Status.State != status.State || cr.Status.Error != status.Error {
-			log.Info("Restore state changed", "previous", cr.Status.State, "current", status.State)
+		r.recordProgressEvents(cr, cr.Status.Progress, status.Progress)
+		if !equality.Semantic.DeepEqual(cr.Status, status) {
+			if cr.Status.State != status.State {
+				log.Info("Restore state changed", "previous", cr.Status.State, "current", status.State)
+			}
+			stateChanged := cr.Status.State != status.State
 			cr.Status = status
 			uerr := r.updateStatus(ctx, cr)
 			if uerr != nil {
 				log.Error(uerr, "failed to updated restore status", "restore", cr.Name, "backup", cr.Spec.BackupName)
+			} else if stateChanged {
+				metrics.ObserveRestore(cr)
 			}
 		}
 	}()
@@ -140,7 +164,19 @@ func (r *ReconcilePerconaServerMongoDBRestore) Reconcile(ctx context.Context, re
 	}
 
 	switch cr.Status.State {
-	case psmdbv1.RestoreStateReady, psmdbv1.RestoreStateError:
+	case psmdbv1.RestoreStateReady:
+		if cr.Status.Progress.Finishing() {
+			status.Progress = r.reconcileFinishingProgress(ctx, cr)
+			return rr, nil
+		}
+		return reconcile.Result{}, nil
+	case psmdbv1.RestoreStateError:
+		if cr.RollbackPending() {
+			status = r.reconcileRollback(ctx, cr)
+			return rr, nil
+		}
+		return reconcile.Result{}, nil
+	case psmdbv1.RestoreStateRejected:
 		return reconcile.Result{}, nil
 	}
 
@@ -154,11 +190,6 @@ func (r *ReconcilePerconaServerMongoDBRestore) Reconcile(ctx context.Context, re
 		return reconcile.Result{}, errors.Wrap(err, "can cluster restore")
 	}
 
-	bcp, err := r.getBackup(ctx, cr)
-	if err != nil {
-		return rr, errors.Wrap(err, "get backup")
-	}
-
 	var svr *version.ServerVersion
 	svr, err = version.Server(r.clientcmd)
 	if err != nil {
@@ -169,6 +200,19 @@ func (r *ReconcilePerconaServerMongoDBRestore) Reconcile(ctx context.Context, re
 		return rr, errors.Wrapf(err, "set defaults for %s/%s", cluster.Namespace, cluster.Name)
 	}
 
+	if cr.Spec.DryRun {
+		status, err = r.reconcileDryRun(ctx, cr, cluster)
+		if errors.Is(err, errWaitingPBM) {
+			err = nil
+		}
+		return rr, err
+	}
+
+	bcp, err := r.getBackup(ctx, cr)
+	if err != nil {
+		return rr, errors.Wrap(err, "get backup")
+	}
+
 	switch bcp.Status.State {
 	case psmdbv1.BackupStateError:
 		err = errors.New("backup is in error state")
@@ -178,6 +222,11 @@ func (r *ReconcilePerconaServerMongoDBRestore) Reconcile(ctx context.Context, re
 		return reconcile.Result{}, errors.New("backup is not ready")
 	}
 
+	if cr.Status.State == psmdbv1.RestoreStateNew && cr.Status.Rollback != nil && cluster.Status.State != psmdbv1.AppStateReady {
+		log.Info("Waiting for cluster to be ready to retry restore", "cluster", cluster.Name, "attempt", cr.Status.Rollback.Attempts+1)
+		return rr, nil
+	}
+
 	if cr.Status.State == psmdbv1.RestoreStateNew {
 		err = r.validate(ctx, cr, cluster)
 		if err != nil {
@@ -195,7 +244,7 @@ func (r *ReconcilePerconaServerMongoDBRestore) Reconcile(ctx context.Context, re
 		if err != nil {
 			return rr, errors.Wrap(err, "reconcile logical restore")
 		}
-	case defs.PhysicalBackup:
+	case defs.PhysicalBackup, defs.IncrementalBackup:
 		status, err = r.reconcilePhysicalRestore(ctx, cr, bcp, cluster)
 		if err != nil {
 			return rr, errors.Wrap(err, "reconcile physical restore")
@@ -205,6 +254,26 @@ func (r *ReconcilePerconaServerMongoDBRestore) Reconcile(ctx context.Context, re
 	return rr, nil
 }
 
+// reconcileFinishingProgress tracks the restarts of the nodes and the PBM resync
+// after the data of a physical restore is restored. Failures are only logged
+// since the restore itself is already finished.
+func (r *ReconcilePerconaServerMongoDBRestore) reconcileFinishingProgress(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore) *psmdbv1.RestoreProgress {
+	log := logf.FromContext(ctx)
+
+	cluster := new(psmdbv1.PerconaServerMongoDB)
+	if err := r.client.Get(ctx, types.NamespacedName{Name: cr.Spec.ClusterName, Namespace: cr.Namespace}, cluster); err != nil {
+		log.Error(err, "failed to get cluster to track restore progress", "cluster", cr.Spec.ClusterName)
+		return cr.Status.Progress
+	}
+
+	progress, err := r.finishingProgress(ctx, cluster, cr.Status.Progress)
+	if err != nil {
+		log.Error(err, "failed to track restore progress")
+	}
+
+	return progress
+}
+
 func (r *ReconcilePerconaServerMongoDBRestore) getStorage(cr *psmdbv1.PerconaServerMongoDBRestore, cluster *psmdbv1.PerconaServerMongoDB, storageName string) (psmdbv1.BackupStorageSpec, error) {
 	if len(storageName) > 0 {
 		storage, ok := cluster.Spec.Backup.Storages[storageName]
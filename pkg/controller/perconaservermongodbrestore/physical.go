@@ -4,11 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"gopkg.in/yaml.v2"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -20,20 +20,24 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/percona/percona-backup-mongodb/pbm/config"
+	"github.com/percona/percona-backup-mongodb/pbm/ctrl"
 	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/restore"
+	"github.com/percona/percona-backup-mongodb/pbm/storage"
 
 	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
 	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/mongo"
 )
 
-var anotherOpBackoff = wait.Backoff{
-	Steps:    13,
-	Duration: time.Second,
+var pbmOperationBackoff = wait.Backoff{
+	Duration: 5 * time.Second,
 	Factor:   2.0,
-	Jitter:   0.1,
-	Cap:      15 * time.Minute,
+	Cap:      time.Hour,
+	Steps:    12,
 }
 
 // reconcilePhysicalRestore performs a physical restore of a Percona Server for MongoDB from a backup.
@@ -43,23 +47,18 @@ func (r *ReconcilePerconaServerMongoDBRestore) reconcilePhysicalRestore(ctx cont
 
 	status := cr.Status
 
-	replsets := cluster.Spec.Replsets
-	if cluster.Spec.Sharding.Enabled {
-		replsets = append(replsets, cluster.Spec.Sharding.ConfigsvrReplSet)
+	if status.Progress == nil {
+		status.Progress = progressWithPhase(nil, psmdbv1.RestorePhasePreparingStatefulSets, metav1.Now())
 	}
 
 	if cr.Status.State == psmdbv1.RestoreStateNew {
-		pod := corev1.Pod{}
-		podName := replsets[0].PodName(cluster, 0)
-		if err := r.client.Get(ctx, types.NamespacedName{Name: podName, Namespace: cluster.Namespace}, &pod); err != nil {
-			return status, errors.Wrapf(err, "get pod/%s", podName)
+		pbmc, err := r.newPBMFunc(ctx, r.client, cluster)
+		if err != nil {
+			return status, errors.Wrap(err, "create pbm object")
 		}
+		defer pbmc.Close(ctx)
 
-		if err := retry.OnError(anotherOpBackoff, func(err error) bool {
-			return strings.Contains(err.Error(), "another operation")
-		}, func() error {
-			return r.disablePITR(ctx, &pod)
-		}); err != nil {
+		if err := disablePITR(ctx, pbmc); err != nil {
 			return status, errors.Wrap(err, "disable pitr")
 		}
 
@@ -67,9 +66,11 @@ func (r *ReconcilePerconaServerMongoDBRestore) reconcilePhysicalRestore(ctx cont
 			var ts string
 			switch cr.Spec.PITR.Type {
 			case psmdbv1.PITRestoreTypeDate:
-				ts = cr.Spec.PITR.Date.Format("2006-01-02T15:04:05")
+				ts = psmdbv1.PITRTarget(primitive.Timestamp{T: uint32(cr.Spec.PITR.Date.Unix())})
+			case psmdbv1.PITRestoreTypeTimestamp:
+				ts = psmdbv1.PITRTarget(cr.Spec.PITR.Timestamp.BSON())
 			case psmdbv1.PITRestoreTypeLatest:
-				ts, err = r.getLatestChunkTS(ctx, &pod)
+				ts, err = getLatestChunkTS(ctx, pbmc)
 				if err != nil {
 					return status, errors.Wrap(err, "get latest chunk timestamp")
 				}
@@ -85,7 +86,7 @@ func (r *ReconcilePerconaServerMongoDBRestore) reconcilePhysicalRestore(ctx cont
 		status.State = psmdbv1.RestoreStateWaiting
 	}
 
-	if err := r.prepareStatefulSetsForPhysicalRestore(ctx, cluster); err != nil {
+	if err := r.prepareStatefulSetsForPhysicalRestore(ctx, cr, cluster); err != nil {
 		return status, errors.Wrap(err, "prepare statefulsets for physical restore")
 	}
 
@@ -115,122 +116,78 @@ func (r *ReconcilePerconaServerMongoDBRestore) reconcilePhysicalRestore(ctx cont
 		}
 	}
 
-	stdoutBuf := &bytes.Buffer{}
-	stderrBuf := &bytes.Buffer{}
-
 	if cr.Status.State == psmdbv1.RestoreStateWaiting {
-		rs := replsets[0]
-
-		pod := corev1.Pod{}
-		if err := r.client.Get(ctx, types.NamespacedName{Name: rs.PodName(cluster, 0), Namespace: cluster.Namespace}, &pod); err != nil {
-			return status, errors.Wrap(err, "get pod")
+		cfg, err := r.restorePBMConfig(ctx, cr, cluster, bcp)
+		if err != nil {
+			return status, err
 		}
 
-		log.V(1).Info("Checking PBM operations for replset", "replset", rs.Name, "pod", rs.PodName(cluster, 0))
+		pbmc, err := r.newPBMFunc(ctx, r.client, cluster)
+		if err != nil {
+			return status, errors.Wrap(err, "create pbm object")
+		}
+		defer pbmc.Close(ctx)
 
-		err := retry.OnError(retry.DefaultBackoff, func(err error) bool { return true }, func() error {
-			stdoutBuf.Reset()
-			stderrBuf.Reset()
+		log.Info("Set PBM configuration", "storage", cfg.Storage.Type)
 
-			command := []string{"/opt/percona/pbm", "config", "--file", "/etc/pbm/pbm_config.yaml"}
-			log.Info("Set PBM configuration", "command", command, "pod", pod.Name)
+		if err := pbmc.SetConfig(ctx, &cfg); err != nil {
+			return status, errors.Wrap(err, "set PBM config")
+		}
 
-			err := r.clientcmd.Exec(ctx, &pod, "mongod", command, nil, stdoutBuf, stderrBuf, false)
-			if err != nil {
-				log.Error(err, "failed to set PBM configuration")
+		// filesystem storage is mounted only into the pods, the operator can't resync it
+		if cfg.Storage.Type != storage.Filesystem {
+			if err := pbmc.ResyncStorage(ctx, &cfg.Storage); err != nil {
+				return status, errors.Wrap(err, "resync storage")
 			}
-
-			return err
-		})
-		if err != nil {
-			return status, errors.Wrapf(err, "resync config stderr: %s stdout: %s", stderrBuf.String(), stdoutBuf.String())
 		}
 
-		time.Sleep(5 * time.Second) // wait until pbm will start resync
-		if err := r.waitForPBMOperationsToFinish(ctx, &pod); err != nil {
+		if err := r.waitForPBMOperationsToFinish(ctx, cluster); err != nil {
 			return status, err
 		}
 
-		var restoreCommand []string
-		if cr.Spec.PITR != nil {
-			restoreCommand = []string{"/opt/percona/pbm", "restore", "--base-snapshot", bcp.Status.PBMname, "--time", cr.Status.PITRTarget, "--out", "json"}
-		} else {
-			restoreCommand = []string{"/opt/percona/pbm", "restore", bcp.Status.PBMname, "--out", "json"}
+		if err := r.setPBMStorageProfile(ctx, cr, cluster, bcp); err != nil {
+			return status, errors.Wrap(err, "set PBM storage profile")
 		}
 
-		err = retry.OnError(anotherOpBackoff, func(err error) bool {
-			return strings.Contains(err.Error(), "another operation")
-		}, func() error {
-			log.Info("Starting restore", "command", restoreCommand, "pod", pod.Name)
-
-			stdoutBuf.Reset()
-			stderrBuf.Reset()
-
-			err := r.clientcmd.Exec(ctx, &pod, "mongod", restoreCommand, nil, stdoutBuf, stderrBuf, false)
+		var target primitive.Timestamp
+		if cr.Spec.PITR != nil {
+			target, err = psmdbv1.ParsePITRTarget(cr.Status.PITRTarget)
 			if err != nil {
-				log.Error(nil, "Restore failed to start", "pod", pod.Name, "stderr", stderrBuf.String(), "stdout", stdoutBuf.String())
-				return errors.Wrapf(err, "start restore stderr: %s stdout: %s", stderrBuf.String(), stdoutBuf.String())
+				return status, errors.Wrap(err, "parse PITR target")
 			}
-
-			log.Info("Restore started", "pod", pod.Name)
-
-			return nil
-		})
-		if err != nil {
-			return status, err
 		}
 
-		var out struct {
-			Name    string `json:"name"`
-			Storage string `json:"storage"`
+		rName := time.Now().UTC().Format(time.RFC3339Nano)
+		cmd := ctrl.Cmd{
+			Cmd: ctrl.CmdRestore,
+			Restore: &ctrl.RestoreCmd{
+				Name:       rName,
+				BackupName: bcp.Status.PBMname,
+				OplogTS:    target,
+				RSMap:      cr.Spec.ReplsetRemapping,
+			},
 		}
-		if err := json.Unmarshal(stdoutBuf.Bytes(), &out); err != nil {
-			return status, errors.Wrap(err, "unmarshal PBM restore output")
+
+		log.Info("Starting restore", "restoreCmd", cmd.Restore)
+		if err := pbmc.SendCmd(ctx, cmd); err != nil {
+			return status, errors.Wrap(err, "send restore cmd")
 		}
 
 		status.State = psmdbv1.RestoreStateRequested
-		status.PBMname = out.Name
+		status.PBMname = rName
 
 		return status, nil
 	}
 
-	meta := backup.BackupMeta{}
-
-	err = retry.OnError(retry.DefaultBackoff, func(err error) bool {
-		return strings.Contains(err.Error(), "container is not created or running") || strings.Contains(err.Error(), "error dialing backend: No agent available")
-	}, func() error {
-		stdoutBuf.Reset()
-		stderrBuf.Reset()
-
-		command := []string{
-			"/opt/percona/pbm", "describe-restore", cr.Status.PBMname,
-			"--config", "/etc/pbm/pbm_config.yaml",
-			"--out", "json",
-		}
-
-		pod := corev1.Pod{}
-		if err := r.client.Get(ctx, types.NamespacedName{Name: replsets[0].PodName(cluster, 0), Namespace: cluster.Namespace}, &pod); err != nil {
-			return errors.Wrap(err, "get pod")
-		}
-
-		log.V(1).Info("Check restore status", "command", command, "pod", pod.Name)
-
-		if err := r.clientcmd.Exec(ctx, &pod, "mongod", command, nil, stdoutBuf, stderrBuf, false); err != nil {
-			return errors.Wrapf(err, "describe restore stderr: %s stdout: %s", stderrBuf.String(), stdoutBuf.String())
-		}
-
-		return nil
-	})
+	meta, err := r.getPhysRestoreMeta(ctx, cr, cluster, bcp)
 	if err != nil {
 		return status, err
 	}
 
-	if err := json.Unmarshal(stdoutBuf.Bytes(), &meta); err != nil {
-		return status, errors.Wrap(err, "unmarshal PBM describe-restore output")
-	}
-
 	log.V(1).Info("PBM restore status", "status", meta)
 
+	status.Progress = restoreProgress(cr.Status.Progress, meta, metav1.Now())
+
 	switch meta.Status {
 	case defs.StatusStarting:
 		for _, rs := range meta.Replsets {
@@ -241,7 +198,7 @@ func (r *ReconcilePerconaServerMongoDBRestore) reconcilePhysicalRestore(ctx cont
 		}
 	case defs.StatusError:
 		status.State = psmdbv1.RestoreStateError
-		status.Error = meta.Err
+		status.Error = meta.Error
 	case defs.StatusRunning:
 		status.State = psmdbv1.RestoreStateRunning
 	case defs.StatusDone:
@@ -332,6 +289,11 @@ func (r *ReconcilePerconaServerMongoDBRestore) reconcilePhysicalRestore(ctx cont
 			return status, errors.Wrapf(err, "annotate psmdb/%s for PBM resync", cluster.Name)
 		}
 
+		if err := r.deleteOriginalStatefulSets(ctx, cr); err != nil {
+			return status, err
+		}
+
+		status.Progress = progressWithPhase(status.Progress, psmdbv1.RestorePhaseRestartingNodes, metav1.Now())
 	}
 
 	return status, nil
@@ -343,6 +305,7 @@ func (r *ReconcilePerconaServerMongoDBRestore) reconcilePhysicalRestore(ctx cont
 // - Adding an init container that installs necessary tools for backup and restore.
 // - Removing the existing backup-agent container.
 // - Appending a volume for backup configuration.
+// - Mounting volumes of filesystem backup storages into the mongod container.
 // - Adjusting the primary container's command, environment variables, and volume mounts for the restore process.
 // It returns an error if there's any issue during the update or if the backup-agent container is not found.
 func (r *ReconcilePerconaServerMongoDBRestore) updateStatefulSetForPhysicalRestore(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, namespacedName types.NamespacedName) error {
@@ -397,6 +360,9 @@ func (r *ReconcilePerconaServerMongoDBRestore) updateStatefulSetForPhysicalResto
 		ReadOnly:  true,
 	})
 	sts.Spec.Template.Spec.Containers[0].VolumeMounts = append(sts.Spec.Template.Spec.Containers[0].VolumeMounts, cluster.Spec.Backup.VolumeMounts...)
+	// volumes of filesystem storages are already in the statefulset, they were mounted into the backup-agent container
+	_, stgMounts := psmdb.BackupStorageVolumes(cluster)
+	sts.Spec.Template.Spec.Containers[0].VolumeMounts = append(sts.Spec.Template.Spec.Containers[0].VolumeMounts, stgMounts...)
 	sts.Spec.Template.Spec.Containers[0].Command = []string{"/opt/percona/physical-restore-ps-entry.sh"}
 	sts.Spec.Template.Spec.Containers[0].Env = append(sts.Spec.Template.Spec.Containers[0].Env, []corev1.EnvVar{
 		{
@@ -444,7 +410,7 @@ func (r *ReconcilePerconaServerMongoDBRestore) updateStatefulSetForPhysicalResto
 	return nil
 }
 
-func (r *ReconcilePerconaServerMongoDBRestore) prepareStatefulSetsForPhysicalRestore(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB) error {
+func (r *ReconcilePerconaServerMongoDBRestore) prepareStatefulSetsForPhysicalRestore(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore, cluster *psmdbv1.PerconaServerMongoDB) error {
 	log := logf.FromContext(ctx)
 
 	replsets := cluster.Spec.Replsets
@@ -468,6 +434,10 @@ func (r *ReconcilePerconaServerMongoDBRestore) prepareStatefulSetsForPhysicalRes
 
 		log.Info("Preparing statefulset for physical restore", "name", stsName)
 
+		if err := r.saveOriginalStatefulSet(ctx, cr, cluster, nn); err != nil {
+			return errors.Wrapf(err, "save original statefulset %s", stsName)
+		}
+
 		err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 			return r.updateStatefulSetForPhysicalRestore(ctx, cluster, types.NamespacedName{Namespace: cluster.Namespace, Name: stsName})
 		})
@@ -481,6 +451,10 @@ func (r *ReconcilePerconaServerMongoDBRestore) prepareStatefulSetsForPhysicalRes
 
 			log.Info("Preparing statefulset for physical restore", "name", stsName)
 
+			if err := r.saveOriginalStatefulSet(ctx, cr, cluster, nn); err != nil {
+				return errors.Wrapf(err, "save original statefulset %s", stsName)
+			}
+
 			err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 				return r.updateStatefulSetForPhysicalRestore(ctx, cluster, nn)
 			})
@@ -495,6 +469,10 @@ func (r *ReconcilePerconaServerMongoDBRestore) prepareStatefulSetsForPhysicalRes
 
 			log.Info("Preparing statefulset for physical restore", "name", stsName)
 
+			if err := r.saveOriginalStatefulSet(ctx, cr, cluster, nn); err != nil {
+				return errors.Wrapf(err, "save original statefulset %s", stsName)
+			}
+
 			err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 				sts := appsv1.StatefulSet{
 					ObjectMeta: metav1.ObjectMeta{
@@ -557,75 +535,73 @@ func (r *ReconcilePerconaServerMongoDBRestore) getUserCredentials(ctx context.Co
 	return creds, nil
 }
 
-func (r *ReconcilePerconaServerMongoDBRestore) runMongosh(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, pod *corev1.Pod, cmd []string) (*bytes.Buffer, *bytes.Buffer, error) {
-	log := logf.FromContext(ctx)
-
-	stdoutBuf := &bytes.Buffer{}
-	stderrBuf := &bytes.Buffer{}
-
-	if err := r.clientcmd.Exec(ctx, pod, "mongod", cmd, nil, stdoutBuf, stderrBuf, false); err != nil {
-		log.V(1).Info("Cmd failed", "stdout", stdoutBuf.String(), "stderr", stderrBuf.String())
-		return stdoutBuf, stderrBuf, errors.Wrap(err, "cmd failed")
-	}
-	log.V(1).Info("Cmd succeeded", "stdout", stdoutBuf.String(), "stderr", stderrBuf.String())
-
-	return stdoutBuf, stderrBuf, nil
-}
-
-func (r *ReconcilePerconaServerMongoDBRestore) runIsMaster(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, pod *corev1.Pod) (bool, error) {
+// podMongoClient connects directly to mongod in the pod as the cluster admin.
+func (r *ReconcilePerconaServerMongoDBRestore) podMongoClient(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, rs *psmdbv1.ReplsetSpec, pod *corev1.Pod) (mongo.Client, error) {
 	creds, err := r.getUserCredentials(ctx, cluster, psmdbv1.RoleClusterAdmin)
 	if err != nil {
-		return false, errors.Wrapf(err, "get %s credentials", psmdbv1.RoleClusterAdmin)
+		return nil, errors.Wrapf(err, "get %s credentials", psmdbv1.RoleClusterAdmin)
 	}
 
-	mongo60, err := cluster.CompareMongoDBVersion("6.0")
+	host, err := psmdb.MongoHost(ctx, r.client, cluster, cluster.Spec.ClusterServiceDNSMode, rs, rs.Expose.Enabled, *pod)
 	if err != nil {
-		return false, errors.Wrap(err, "compare mongo version")
+		return nil, errors.Wrap(err, "get mongo host")
 	}
 
-	mongoClient := "mongo"
-	if mongo60 >= 0 {
-		mongoClient = "mongosh"
-	}
+	return r.newStandaloneClientFunc(ctx, r.client, cluster, creds, host, cluster.TLSEnabled())
+}
 
-	c := strings.Join([]string{
-		mongoClient, "--quiet", "-u", creds.Username, "-p", creds.Password, "--eval", "'db.isMaster().ismaster'",
-		"|", "tail", "-n", "1",
-		"|", "grep", "-Eo", "'(true|false)'",
-	}, " ")
-	cmd := []string{"bash", "-c", c}
+func (r *ReconcilePerconaServerMongoDBRestore) isPrimary(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, rs *psmdbv1.ReplsetSpec, pod *corev1.Pod) (bool, error) {
+	cli, err := r.podMongoClient(ctx, cluster, rs, pod)
+	if err != nil {
+		return false, errors.Wrapf(err, "connect to pod %s", pod.Name)
+	}
+	defer func() {
+		if err := cli.Disconnect(ctx); err != nil {
+			logf.FromContext(ctx).Error(err, "failed to close connection", "pod", pod.Name)
+		}
+	}()
 
-	stdoutBuf, _, err := r.runMongosh(ctx, cluster, pod, cmd)
+	resp, err := cli.IsMaster(ctx)
 	if err != nil {
 		return false, errors.Wrap(err, "run isMaster")
 	}
 
-	return strings.TrimSuffix(stdoutBuf.String(), "\n") == "true", nil
+	return resp.IsMaster, nil
 }
 
-func (r *ReconcilePerconaServerMongoDBRestore) makePrimary(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, pod *corev1.Pod, targetPod string) error {
-	jsTempl := `cfg = rs.config(); podZero = cfg.members.find(member => member.tags.podName === "%s"); podZero.priority += 1; rs.reconfig(cfg)`
-
-	creds, err := r.getUserCredentials(ctx, cluster, psmdbv1.RoleClusterAdmin)
+// makePrimary increases the priority of the target pod in the replset config,
+// so it's elected as the primary.
+func (r *ReconcilePerconaServerMongoDBRestore) makePrimary(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, rs *psmdbv1.ReplsetSpec, pod *corev1.Pod, targetPod string) error {
+	cli, err := r.podMongoClient(ctx, cluster, rs, pod)
 	if err != nil {
-		return errors.Wrapf(err, "get %s credentials", psmdbv1.RoleClusterAdmin)
+		return errors.Wrapf(err, "connect to pod %s", pod.Name)
 	}
+	defer func() {
+		if err := cli.Disconnect(ctx); err != nil {
+			logf.FromContext(ctx).Error(err, "failed to close connection", "pod", pod.Name)
+		}
+	}()
 
-	mongo60, err := cluster.CompareMongoDBVersion("6.0")
+	cfg, err := cli.ReadConfig(ctx)
 	if err != nil {
-		return errors.Wrap(err, "compare mongo version")
+		return errors.Wrap(err, "read replset config")
 	}
 
-	mongoClient := "mongo"
-	if mongo60 >= 0 {
-		mongoClient = "mongosh"
+	found := false
+	for i := range cfg.Members {
+		if cfg.Members[i].Tags["podName"] == targetPod {
+			cfg.Members[i].Priority++
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.Errorf("member with podName tag %s is not found in replset config", targetPod)
 	}
 
-	cmd := []string{mongoClient, "--quiet", "-u", creds.Username, "-p", creds.Password, "--eval", fmt.Sprintf(jsTempl, targetPod)}
-
-	_, _, err = r.runMongosh(ctx, cluster, pod, cmd)
-	if err != nil {
-		return errors.Wrap(err, "run isMaster")
+	cfg.Version++
+	if err := cli.WriteConfig(ctx, cfg, false); err != nil {
+		return errors.Wrap(err, "write replset config")
 	}
 
 	return nil
@@ -648,8 +624,9 @@ func (r *ReconcilePerconaServerMongoDBRestore) prepareReplsetsForPhysicalRestore
 		}
 
 		for _, pod := range podList.Items {
-			isMaster, err := r.runIsMaster(ctx, cluster, &pod)
+			isMaster, err := r.isPrimary(ctx, cluster, rs, &pod)
 			if err != nil {
+				log.V(1).Info("Failed to check if pod is primary", "pod", pod.Name, "error", err.Error())
 				continue
 			}
 
@@ -659,7 +636,7 @@ func (r *ReconcilePerconaServerMongoDBRestore) prepareReplsetsForPhysicalRestore
 			}
 
 			podZero := rs.PodName(cluster, 0)
-			if err = r.makePrimary(ctx, cluster, &pod, podZero); err != nil {
+			if err = r.makePrimary(ctx, cluster, rs, &pod, podZero); err != nil {
 				return errors.Wrapf(err, "make %s primary", podZero)
 			}
 		}
@@ -686,7 +663,7 @@ func (r *ReconcilePerconaServerMongoDBRestore) checkIfReplsetsAreReadyForPhysica
 			return false, errors.Wrapf(err, "get pod %s", podZero)
 		}
 
-		isMaster, err := r.runIsMaster(ctx, cluster, &pod)
+		isMaster, err := r.isPrimary(ctx, cluster, rs, &pod)
 		if err != nil {
 			return false, errors.Wrap(err, "check if pod zero is primary")
 		}
@@ -701,6 +678,109 @@ func (r *ReconcilePerconaServerMongoDBRestore) checkIfReplsetsAreReadyForPhysica
 	return true, nil
 }
 
+// restorePBMConfig returns PBM config used during the physical restore.
+func (r *ReconcilePerconaServerMongoDBRestore) restorePBMConfig(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore, cluster *psmdbv1.PerconaServerMongoDB, bcp *psmdbv1.PerconaServerMongoDBBackup) (config.Config, error) {
+	stg, err := r.getStorage(cr, cluster, bcp.Spec.StorageName)
+	if err != nil {
+		return config.Config{}, errors.Wrap(err, "get storage")
+	}
+
+	// Backups from storages other than main are restored using config profiles,
+	// PBM main storage is kept to not break PITR oplog after restore.
+	if cluster.Spec.Backup.StorageProfile(bcp.Spec.StorageName) != "" {
+		_, stg, _ = cluster.Spec.Backup.MainStorage()
+	}
+
+	pbmConfig, err := backup.GetPBMConfig(ctx, r.client, cluster, stg)
+	if err != nil {
+		return config.Config{}, errors.Wrap(err, "get PBM config")
+	}
+
+	pbmConfig.PITR.Enabled = false
+
+	return pbmConfig, nil
+}
+
+// getPhysRestoreMeta reads the restore metadata from the PBM main storage,
+// the restore is not reachable through the database while mongod is stopped.
+// Filesystem storage is mounted only into the pods, so the metadata
+// of a restore from it is read with pbm describe-restore in the first pod.
+func (r *ReconcilePerconaServerMongoDBRestore) getPhysRestoreMeta(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore, cluster *psmdbv1.PerconaServerMongoDB, bcp *psmdbv1.PerconaServerMongoDBBackup) (*restore.RestoreMeta, error) {
+	cfg, err := r.restorePBMConfig(ctx, cr, cluster, bcp)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Storage.Type == storage.Filesystem {
+		return r.describePhysRestore(ctx, cluster, cr.Status.PBMname)
+	}
+
+	pbmc, err := r.newStoragePBMFunc(ctx, r.client, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "create pbm object")
+	}
+	defer pbmc.Close(ctx)
+
+	var meta *restore.RestoreMeta
+	err = retry.OnError(retry.DefaultBackoff, func(err error) bool { return true }, func() error {
+		var err error
+		meta, err = pbmc.GetPhysRestoreMeta(ctx, &cfg.Storage, cr.Status.PBMname)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "get PBM restore meta")
+	}
+
+	return meta, nil
+}
+
+func (r *ReconcilePerconaServerMongoDBRestore) describePhysRestore(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, name string) (*restore.RestoreMeta, error) {
+	log := logf.FromContext(ctx)
+
+	stdoutBuf := &bytes.Buffer{}
+	stderrBuf := &bytes.Buffer{}
+
+	command := []string{
+		"/opt/percona/pbm", "describe-restore", name,
+		"--config", "/etc/pbm/pbm_config.yaml",
+		"--out", "json",
+	}
+
+	err := retry.OnError(retry.DefaultBackoff, func(err error) bool {
+		return strings.Contains(err.Error(), "container is not created or running") || strings.Contains(err.Error(), "error dialing backend: No agent available")
+	}, func() error {
+		stdoutBuf.Reset()
+		stderrBuf.Reset()
+
+		pod := corev1.Pod{}
+		if err := r.client.Get(ctx, types.NamespacedName{Name: cluster.Spec.Replsets[0].PodName(cluster, 0), Namespace: cluster.Namespace}, &pod); err != nil {
+			return errors.Wrap(err, "get pod")
+		}
+
+		log.V(1).Info("Check restore status", "command", command, "pod", pod.Name)
+
+		if err := r.clientcmd.Exec(ctx, &pod, "mongod", command, nil, stdoutBuf, stderrBuf, false); err != nil {
+			return errors.Wrapf(err, "describe restore stderr: %s stdout: %s", stderrBuf.String(), stdoutBuf.String())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDescribeRestore(stdoutBuf.Bytes())
+}
+
+// parseDescribeRestore parses the JSON output of pbm describe-restore,
+// it has the same fields as the restore metadata the progress is built from.
+func parseDescribeRestore(out []byte) (*restore.RestoreMeta, error) {
+	meta := new(restore.RestoreMeta)
+	if err := json.Unmarshal(out, meta); err != nil {
+		return nil, errors.Wrap(err, "unmarshal PBM describe-restore output")
+	}
+	return meta, nil
+}
+
 func (r *ReconcilePerconaServerMongoDBRestore) updatePBMConfigSecret(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore, cluster *psmdbv1.PerconaServerMongoDB, bcp *psmdbv1.PerconaServerMongoDBBackup) error {
 	log := logf.FromContext(ctx)
 
@@ -710,18 +790,11 @@ func (r *ReconcilePerconaServerMongoDBRestore) updatePBMConfigSecret(ctx context
 		return errors.Wrap(err, "get PBM config secret")
 	}
 
-	storage, err := r.getStorage(cr, cluster, bcp.Spec.StorageName)
-	if err != nil {
-		return errors.Wrap(err, "get storage")
-	}
-
-	pbmConfig, err := backup.GetPBMConfig(ctx, r.client, cluster, storage)
+	pbmConfig, err := r.restorePBMConfig(ctx, cr, cluster, bcp)
 	if err != nil {
-		return errors.Wrap(err, "get PBM config")
+		return err
 	}
 
-	pbmConfig.PITR.Enabled = false
-
 	confBytes, err := yaml.Marshal(pbmConfig)
 	if err != nil {
 		return errors.Wrap(err, "marshal PBM config to yaml")
@@ -754,6 +827,38 @@ func (r *ReconcilePerconaServerMongoDBRestore) updatePBMConfigSecret(ctx context
 	return nil
 }
 
+// setPBMStorageProfile saves and resyncs PBM config profile of the backup storage
+// if the backup is not in the main storage.
+func (r *ReconcilePerconaServerMongoDBRestore) setPBMStorageProfile(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore, cluster *psmdbv1.PerconaServerMongoDB, bcp *psmdbv1.PerconaServerMongoDBBackup) error {
+	profile := cluster.Spec.Backup.StorageProfile(bcp.Spec.StorageName)
+	if profile == "" {
+		return nil
+	}
+
+	storage, err := r.getStorage(cr, cluster, bcp.Spec.StorageName)
+	if err != nil {
+		return errors.Wrap(err, "get storage")
+	}
+
+	pbmc, err := r.newPBMFunc(ctx, r.client, cluster)
+	if err != nil {
+		return errors.Wrap(err, "create pbm object")
+	}
+	defer pbmc.Close(ctx)
+
+	logf.FromContext(ctx).Info("Set PBM config profile", "profile", profile)
+
+	if err := pbmc.GetNSetProfile(ctx, r.client, cluster, profile, storage); err != nil {
+		return errors.Wrap(err, "set pbm config profile")
+	}
+
+	if err := pbmc.ResyncProfile(ctx, profile); err != nil {
+		return errors.Wrap(err, "resync pbm config profile")
+	}
+
+	return r.waitForPBMOperationsToFinish(ctx, cluster)
+}
+
 func (r *ReconcilePerconaServerMongoDBRestore) getReplsetPods(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, rs *psmdbv1.ReplsetSpec) (corev1.PodList, error) {
 	mongodPods := corev1.PodList{}
 
@@ -816,63 +921,19 @@ func (r *ReconcilePerconaServerMongoDBRestore) checkIfStatefulSetsAreReadyForPhy
 	return true, nil
 }
 
-func (r *ReconcilePerconaServerMongoDBRestore) getLatestChunkTS(ctx context.Context, pod *corev1.Pod) (string, error) {
-	stdoutBuf := &bytes.Buffer{}
-	stderrBuf := &bytes.Buffer{}
-
-	container := "mongod"
-	pbmBinary := "/opt/percona/pbm"
-	for _, c := range pod.Spec.Containers {
-		if c.Name == naming.ContainerBackupAgent {
-			container = c.Name
-			pbmBinary = "pbm"
-		}
-	}
-
-	command := []string{pbmBinary, "status", "--out", "json"}
-	if err := r.clientcmd.Exec(ctx, pod, container, command, nil, stdoutBuf, stderrBuf, false); err != nil {
-		return "", errors.Wrapf(err, "get PBM status stderr: %s stdout: %s", stderrBuf.String(), stdoutBuf.String())
-	}
-
-	var pbmStatus struct {
-		Backups struct {
-			Chunks struct {
-				Timelines []struct {
-					Range struct {
-						Start uint32 `json:"start"`
-						End   uint32 `json:"end"`
-					} `json:"range"`
-				} `json:"pitrChunks"`
-			} `json:"pitrChunks"`
-		} `json:"backups"`
-	}
-
-	if err := json.Unmarshal(stdoutBuf.Bytes(), &pbmStatus); err != nil {
-		return "", errors.Wrap(err, "unmarshal PBM status output")
+// getLatestChunkTS returns the end of the latest PITR timeline as the restore target.
+func getLatestChunkTS(ctx context.Context, pbmc backup.PBM) (string, error) {
+	tl, err := pbmc.GetLatestTimelinePITR(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "get latest PITR timeline")
 	}
 
-	latest := pbmStatus.Backups.Chunks.Timelines[len(pbmStatus.Backups.Chunks.Timelines)-1].Range.End
-	ts := time.Unix(int64(latest), 0).UTC()
-
-	return ts.Format("2006-01-02T15:04:05"), nil
+	return psmdbv1.PITRTarget(primitive.Timestamp{T: tl.End}), nil
 }
 
-func (r *ReconcilePerconaServerMongoDBRestore) disablePITR(ctx context.Context, pod *corev1.Pod) error {
-	stdoutBuf := &bytes.Buffer{}
-	stderrBuf := &bytes.Buffer{}
-
-	container := "mongod"
-	pbmBinary := "/opt/percona/pbm"
-	for _, c := range pod.Spec.Containers {
-		if c.Name == naming.ContainerBackupAgent {
-			container = c.Name
-			pbmBinary = "pbm"
-		}
-	}
-
-	command := []string{pbmBinary, "config", "--set", "pitr.enabled=false"}
-	if err := r.clientcmd.Exec(ctx, pod, container, command, nil, stdoutBuf, stderrBuf, false); err != nil {
-		return errors.Wrapf(err, "disable PiTR stderr: %s stdout: %s", stderrBuf.String(), stdoutBuf.String())
+func disablePITR(ctx context.Context, pbmc backup.PBM) error {
+	if err := pbmc.SetConfigVar(ctx, "pitr.enabled", "false"); err != nil {
+		return errors.Wrap(err, "set pitr.enabled")
 	}
 
 	return nil
@@ -885,69 +946,28 @@ func (r *ReconcilePerconaServerMongoDBRestore) pbmConfigName(cluster *psmdbv1.Pe
 	return cluster.Name + "-pbm-config"
 }
 
-func (r *ReconcilePerconaServerMongoDBRestore) waitForPBMOperationsToFinish(ctx context.Context, pod *corev1.Pod) error {
+func (r *ReconcilePerconaServerMongoDBRestore) waitForPBMOperationsToFinish(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB) error {
 	log := logf.FromContext(ctx)
 
-	stdoutBuf := &bytes.Buffer{}
-	stderrBuf := &bytes.Buffer{}
-
-	container := "mongod"
-	pbmBinary := "/opt/percona/pbm"
-	for _, c := range pod.Spec.Containers {
-		if c.Name == naming.ContainerBackupAgent {
-			container = c.Name
-			pbmBinary = "pbm"
-		}
+	pbmc, err := r.newPBMFunc(ctx, r.client, cluster)
+	if err != nil {
+		return errors.Wrap(err, "create pbm object")
 	}
+	defer pbmc.Close(ctx)
 
 	waitErr := errors.New("waiting for PBM operation to finish")
-	err := retry.OnError(wait.Backoff{
-		Duration: 5 * time.Second,
-		Factor:   2.0,
-		Cap:      time.Hour,
-		Steps:    12,
-	}, func(err error) bool { return err == waitErr }, func() error {
-		err := retry.OnError(retry.DefaultBackoff, func(err error) bool { return strings.Contains(err.Error(), "No agent available") }, func() error {
-			stdoutBuf.Reset()
-			stderrBuf.Reset()
-
-			command := []string{pbmBinary, "status", "--out", "json"}
-			err := r.clientcmd.Exec(ctx, pod, container, command, nil, stdoutBuf, stderrBuf, false)
-			if err != nil {
-				log.Error(err, "failed to get PBM status")
-				return err
-			}
-
-			log.V(1).Info("PBM status", "status", stdoutBuf.String())
-
-			return nil
-		})
+	return retry.OnError(pbmOperationBackoff, func(err error) bool { return err == waitErr }, func() error {
+		running, err := pbmc.HasLocks(ctx)
 		if err != nil {
-			return errors.Wrapf(err, "get PBM status stderr: %s stdout: %s", stderrBuf.String(), stdoutBuf.String())
+			return errors.Wrap(err, "check PBM locks")
 		}
 
-		var pbmStatus struct {
-			Running struct {
-				Type string `json:"type,omitempty"`
-				OpId string `json:"opID,omitempty"`
-			} `json:"running"`
-		}
-
-		if err := json.Unmarshal(stdoutBuf.Bytes(), &pbmStatus); err != nil {
-			return errors.Wrap(err, "unmarshal PBM status output")
-		}
-
-		if len(pbmStatus.Running.OpId) == 0 {
+		if !running {
 			return nil
 		}
 
-		log.Info("Waiting for another PBM operation to finish", "type", pbmStatus.Running.Type, "opID", pbmStatus.Running.OpId)
+		log.Info("Waiting for another PBM operation to finish")
 
 		return waitErr
 	})
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
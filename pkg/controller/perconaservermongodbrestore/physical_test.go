@@ -0,0 +1,406 @@
+package perconaservermongodbrestore
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/yaml.v2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/percona/percona-backup-mongodb/pbm/config"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/oplog"
+	"github.com/percona/percona-backup-mongodb/pbm/restore"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
+	fakeBackup "github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup/fake"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/mongo"
+	fakeMongo "github.com/percona/percona-server-mongodb-operator/pkg/psmdb/mongo/fake"
+)
+
+// fakeReplset is the state of a replset shared by the clients of its pods.
+// The member with the highest priority is elected as the primary after reconfig.
+type fakeReplset struct {
+	primary string
+	cfg     mongo.RSConfig
+}
+
+type fakePodClient struct {
+	mongo.Client
+
+	pod string
+	rs  *fakeReplset
+}
+
+func (c *fakePodClient) IsMaster(ctx context.Context) (*mongo.IsMasterResp, error) {
+	return &mongo.IsMasterResp{IsMaster: c.rs.primary == c.pod}, nil
+}
+
+func (c *fakePodClient) ReadConfig(ctx context.Context) (mongo.RSConfig, error) {
+	cfg := c.rs.cfg
+	cfg.Members = slices.Clone(c.rs.cfg.Members)
+	return cfg, nil
+}
+
+func (c *fakePodClient) WriteConfig(ctx context.Context, cfg mongo.RSConfig, force bool) error {
+	if c.rs.primary != c.pod {
+		return errors.New("replSetReconfig: not primary")
+	}
+	if cfg.Version <= c.rs.cfg.Version {
+		return errors.New("replSetReconfig: version must be greater than the current one")
+	}
+
+	c.rs.cfg = cfg
+
+	primary := cfg.Members[0]
+	for _, m := range cfg.Members {
+		if m.Priority > primary.Priority {
+			primary = m
+		}
+	}
+	c.rs.primary = primary.Tags["podName"]
+
+	return nil
+}
+
+func fakeStandaloneClientFunc(rs *fakeReplset) newStandaloneClientFunc {
+	return func(ctx context.Context, k8sclient client.Client, cluster *psmdbv1.PerconaServerMongoDB, c psmdb.Credentials, host string, tlsEnabled bool) (mongo.Client, error) {
+		if c.Username != "clusterAdmin" {
+			return nil, errors.Errorf("unexpected user %s", c.Username)
+		}
+
+		pod, _, _ := strings.Cut(host, ".")
+		return &fakePodClient{Client: fakeMongo.NewClient(), pod: pod, rs: rs}, nil
+	}
+}
+
+func TestPrepareReplsetsForPhysicalRestore(t *testing.T) {
+	ctx := context.Background()
+
+	ns := "physical"
+	cluster := readDefaultCluster(t, "cluster", ns)
+	cluster.Spec.Sharding.Enabled = false
+	rs := cluster.Spec.Replsets[0]
+
+	users := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: psmdbv1.UserSecretName(cluster), Namespace: ns},
+		Data: map[string][]byte{
+			psmdbv1.EnvMongoDBClusterAdminUser:     []byte("clusterAdmin"),
+			psmdbv1.EnvMongoDBClusterAdminPassword: []byte("password"),
+		},
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.Name + "-" + rs.Name,
+			Namespace: ns,
+			Labels:    naming.MongodLabels(cluster, rs),
+		},
+	}
+	objs := []client.Object{cluster, users, sts}
+
+	replset := &fakeReplset{primary: rs.PodName(cluster, 1)}
+	for i := 0; i < int(rs.Size); i++ {
+		pod := rs.PodName(cluster, i)
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pod,
+				Namespace: ns,
+				Labels:    naming.MongodLabels(cluster, rs),
+			},
+		})
+		replset.cfg.Members = append(replset.cfg.Members, mongo.ConfigMember{
+			ID:       i,
+			Priority: mongo.DefaultPriority,
+			Tags:     mongo.ReplsetTags{"podName": pod},
+		})
+	}
+	replset.cfg.Version = 1
+
+	r := fakeReconciler(objs...)
+	r.newStandaloneClientFunc = fakeStandaloneClientFunc(replset)
+
+	ready, err := r.checkIfReplsetsAreReadyForPhysicalRestore(ctx, cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ready {
+		t.Fatal("replset is ready while pod zero is secondary")
+	}
+
+	if err := r.prepareReplsetsForPhysicalRestore(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	if replset.cfg.Version != 2 {
+		t.Errorf("expected config version 2, got %d", replset.cfg.Version)
+	}
+	if p := replset.cfg.Members[0].Priority; p != mongo.DefaultPriority+1 {
+		t.Errorf("expected pod zero priority %d, got %d", mongo.DefaultPriority+1, p)
+	}
+
+	ready, err = r.checkIfReplsetsAreReadyForPhysicalRestore(ctx, cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ready {
+		t.Errorf("replset is not ready, primary is %s", replset.primary)
+	}
+}
+
+type fakePhysicalPBM struct {
+	backup.PBM
+
+	config   map[string]string
+	timeline oplog.Timeline
+	locks    int
+	profiles map[string]psmdbv1.BackupStorageSpec
+	resynced []string
+	meta     map[string]*restore.RestoreMeta
+}
+
+func (p *fakePhysicalPBM) GetPhysRestoreMeta(ctx context.Context, stg *config.StorageConf, name string) (*restore.RestoreMeta, error) {
+	if stg.S3 == nil {
+		return nil, errors.New("unexpected storage")
+	}
+	return p.meta[stg.S3.Bucket+"/"+name], nil
+}
+
+func (p *fakePhysicalPBM) GetNSetProfile(ctx context.Context, k8sclient client.Client, cluster *psmdbv1.PerconaServerMongoDB, name string, stg psmdbv1.BackupStorageSpec) error {
+	p.profiles[name] = stg
+	return nil
+}
+
+func (p *fakePhysicalPBM) ResyncProfile(ctx context.Context, name string) error {
+	p.resynced = append(p.resynced, name)
+	return nil
+}
+
+func (p *fakePhysicalPBM) SetConfigVar(ctx context.Context, key, val string) error {
+	p.config[key] = val
+	return nil
+}
+
+func (p *fakePhysicalPBM) GetLatestTimelinePITR(ctx context.Context) (oplog.Timeline, error) {
+	if p.timeline.End == 0 {
+		return oplog.Timeline{}, backup.ErrNoOplogsForPITR
+	}
+	return p.timeline, nil
+}
+
+func (p *fakePhysicalPBM) HasLocks(ctx context.Context, predicates ...backup.LockHeaderPredicate) (bool, error) {
+	if p.locks == 0 {
+		return false, nil
+	}
+	p.locks--
+	return true, nil
+}
+
+func TestPhysicalRestorePBMSteps(t *testing.T) {
+	ctx := context.Background()
+
+	pbmc, err := fakeBackup.NewPBM(ctx, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakePhysicalPBM{
+		PBM:      pbmc,
+		config:   make(map[string]string),
+		timeline: oplog.Timeline{Start: 100, End: 200},
+		locks:    2,
+	}
+
+	if err := disablePITR(ctx, fake); err != nil {
+		t.Fatal(err)
+	}
+	if fake.config["pitr.enabled"] != "false" {
+		t.Errorf("pitr is not disabled: %v", fake.config)
+	}
+
+	ts, err := getLatestChunkTS(ctx, fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := psmdbv1.PITRTarget(primitive.Timestamp{T: 200}); ts != expected {
+		t.Errorf("expected %s, got %s", expected, ts)
+	}
+
+	if _, err := getLatestChunkTS(ctx, &fakePhysicalPBM{PBM: pbmc}); err == nil {
+		t.Error("expected error without PITR chunks")
+	}
+
+	defer func(b wait.Backoff) { pbmOperationBackoff = b }(pbmOperationBackoff)
+	pbmOperationBackoff = wait.Backoff{Duration: time.Millisecond, Steps: 3}
+
+	r := fakeReconciler()
+	r.newPBMFunc = func(context.Context, client.Client, *psmdbv1.PerconaServerMongoDB) (backup.PBM, error) {
+		return fake, nil
+	}
+
+	cluster := readDefaultCluster(t, "cluster", "physical")
+	if err := r.waitForPBMOperationsToFinish(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if fake.locks != 0 {
+		t.Errorf("PBM operations are not finished, %d left", fake.locks)
+	}
+
+	fake.locks = 5
+	if err := r.waitForPBMOperationsToFinish(ctx, cluster); err == nil {
+		t.Error("expected error while PBM operation is running")
+	}
+}
+
+func TestPhysicalRestoreStorageProfile(t *testing.T) {
+	ctx := context.Background()
+
+	ns := "physical"
+	cluster := readDefaultCluster(t, "cluster", ns)
+	cluster.Spec.Backup.Storages = map[string]psmdbv1.BackupStorageSpec{
+		"main": {
+			Main: true,
+			Type: psmdbv1.BackupStorageS3,
+			S3:   psmdbv1.BackupStorageS3Spec{Bucket: "main"},
+		},
+		"other": {
+			Type: psmdbv1.BackupStorageS3,
+			S3:   psmdbv1.BackupStorageS3Spec{Bucket: "other"},
+		},
+	}
+
+	tests := map[string]struct {
+		storageName string
+		profiles    []string
+	}{
+		"main storage": {
+			storageName: "main",
+		},
+		"profile storage": {
+			storageName: "other",
+			profiles:    []string{"other"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cr := readDefaultRestore(t, "restore", ns)
+			bcp := readDefaultBackup(t, "backup", ns)
+			bcp.Spec.StorageName = tt.storageName
+
+			pbmc, err := fakeBackup.NewPBM(ctx, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fake := &fakePhysicalPBM{PBM: pbmc, profiles: make(map[string]psmdbv1.BackupStorageSpec)}
+
+			r := fakeReconciler(cluster)
+			r.newPBMFunc = func(context.Context, client.Client, *psmdbv1.PerconaServerMongoDB) (backup.PBM, error) {
+				return fake, nil
+			}
+
+			if err := r.updatePBMConfigSecret(ctx, cr, cluster, bcp); err != nil {
+				t.Fatal(err)
+			}
+
+			secret := new(corev1.Secret)
+			if err := r.client.Get(ctx, types.NamespacedName{Name: r.pbmConfigName(cluster), Namespace: ns}, secret); err != nil {
+				t.Fatal(err)
+			}
+			conf := config.Config{}
+			if err := yaml.Unmarshal(secret.Data["pbm_config.yaml"], &conf); err != nil {
+				t.Fatal(err)
+			}
+			if conf.Storage.S3 == nil || conf.Storage.S3.Bucket != "main" {
+				t.Errorf("PBM main storage is not the main storage: %+v", conf.Storage)
+			}
+
+			cr.Status.PBMname = "2024-10-01T10:00:00Z"
+			fake.meta = map[string]*restore.RestoreMeta{"main/" + cr.Status.PBMname: {Status: defs.StatusRunning}}
+			r.newStoragePBMFunc = r.newPBMFunc
+
+			meta, err := r.getPhysRestoreMeta(ctx, cr, cluster, bcp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if meta == nil || meta.Status != defs.StatusRunning {
+				t.Errorf("restore meta is not read from the main storage: %+v", meta)
+			}
+
+			if err := r.setPBMStorageProfile(ctx, cr, cluster, bcp); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(fake.resynced, tt.profiles) {
+				t.Errorf("expected resynced profiles %v, got %v", tt.profiles, fake.resynced)
+			}
+			for _, profile := range tt.profiles {
+				if stg := fake.profiles[profile]; stg.S3.Bucket != profile {
+					t.Errorf("profile %s has wrong storage %+v", profile, stg)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDescribeRestore(t *testing.T) {
+	out := `{
+  "name": "2024-10-01T10:00:00.000000000Z",
+  "opid": "66fbc7f0b5f6b0e1c5a1b2c3",
+  "backup": "2024-09-30T10:00:00Z",
+  "type": "physical",
+  "status": "running",
+  "start": "2024-10-01T10:00:01Z",
+  "last_transition_ts": 1727776805,
+  "last_transition_time": "2024-10-01T10:00:05Z",
+  "replsets": [
+    {
+      "name": "rs0",
+      "status": "error",
+      "last_transition_ts": 1727776805,
+      "last_transition_time": "2024-10-01T10:00:05Z",
+      "error": "download failed",
+      "nodes": [
+        {
+          "name": "cluster-rs0-0.cluster-rs0.physical.svc.cluster.local:27017",
+          "status": "running",
+          "last_transition_ts": 1727776803,
+          "last_transition_time": "2024-10-01T10:00:03Z"
+        }
+      ]
+    }
+  ]
+}`
+
+	meta, err := parseDescribeRestore([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Status != defs.StatusRunning || meta.Type != defs.PhysicalBackup || meta.LastTransitionTS != 1727776805 {
+		t.Errorf("unexpected restore meta: %+v", meta)
+	}
+	if len(meta.Replsets) != 1 {
+		t.Fatalf("expected 1 replset, got %d", len(meta.Replsets))
+	}
+	rs := meta.Replsets[0]
+	if rs.Name != "rs0" || rs.Status != defs.StatusError || rs.Error != "download failed" {
+		t.Errorf("unexpected replset meta: %+v", rs)
+	}
+	if len(rs.Nodes) != 1 || rs.Nodes[0].Status != defs.StatusRunning || rs.Nodes[0].LastTransitionTS != 1727776803 {
+		t.Errorf("unexpected node meta: %+v", rs.Nodes)
+	}
+
+	if _, err := parseDescribeRestore([]byte("Error: get restore meta: not found")); err == nil {
+		t.Error("expected error for non JSON output")
+	}
+}
@@ -0,0 +1,265 @@
+package perconaservermongodbrestore
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/restore"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+)
+
+var restorePhases = []psmdbv1.RestorePhase{
+	psmdbv1.RestorePhasePreparingStatefulSets,
+	psmdbv1.RestorePhaseRestoringData,
+	psmdbv1.RestorePhaseRestartingNodes,
+	psmdbv1.RestorePhaseResyncingCluster,
+	psmdbv1.RestorePhaseDone,
+}
+
+// minPhase returns the least advanced phase. A failed phase is less advanced than any other.
+func minPhase(phases ...psmdbv1.RestorePhase) psmdbv1.RestorePhase {
+	var phase psmdbv1.RestorePhase
+	for i, p := range phases {
+		if p == psmdbv1.RestorePhaseFailed {
+			return p
+		}
+		if i == 0 || slices.Index(restorePhases, p) < slices.Index(restorePhases, phase) {
+			phase = p
+		}
+	}
+	return phase
+}
+
+// lastTransition returns the time of the last phase change.
+func lastTransition(prevPhase, phase psmdbv1.RestorePhase, prevTime *metav1.Time, now metav1.Time) *metav1.Time {
+	if prevPhase == phase && prevTime != nil {
+		return prevTime
+	}
+	return &now
+}
+
+// nodePhase returns the phase of the node restore by its status in PBM.
+func nodePhase(status defs.Status) psmdbv1.RestorePhase {
+	switch status {
+	case defs.StatusError:
+		return psmdbv1.RestorePhaseFailed
+	case defs.StatusDone, defs.StatusPartlyDone:
+		return psmdbv1.RestorePhaseRestartingNodes
+	}
+
+	return psmdbv1.RestorePhaseRestoringData
+}
+
+// restoreProgress returns the progress of the physical restore reported by PBM in meta.
+// It never modifies prev.
+func restoreProgress(prev *psmdbv1.RestoreProgress, meta *restore.RestoreMeta, now metav1.Time) *psmdbv1.RestoreProgress {
+	progress := &psmdbv1.RestoreProgress{Status: string(meta.Status)}
+
+	rsPhases := make([]psmdbv1.RestorePhase, 0, len(meta.Replsets))
+	for _, rs := range meta.Replsets {
+		prevRS := prev.Replset(rs.Name)
+
+		rsp := psmdbv1.RestoreReplsetProgress{
+			Name:   rs.Name,
+			Status: string(rs.Status),
+			Error:  rs.Error,
+		}
+
+		nodePhases := make([]psmdbv1.RestorePhase, 0, len(rs.Nodes))
+		for _, node := range rs.Nodes {
+			prevNode := prevRS.Node(node.Name)
+
+			np := psmdbv1.RestoreNodeProgress{
+				Name:   node.Name,
+				Status: string(node.Status),
+				Error:  node.Error,
+			}
+
+			np.Phase = nodePhase(node.Status)
+
+			var prevPhase psmdbv1.RestorePhase
+			var prevTime *metav1.Time
+			if prevNode != nil {
+				prevPhase, prevTime = prevNode.Phase, prevNode.LastTransition
+			}
+			np.LastTransition = lastTransition(prevPhase, np.Phase, prevTime, now)
+
+			nodePhases = append(nodePhases, np.Phase)
+			rsp.Nodes = append(rsp.Nodes, np)
+		}
+
+		if len(nodePhases) > 0 {
+			rsp.Phase = minPhase(nodePhases...)
+		} else {
+			rsp.Phase = nodePhase(rs.Status)
+		}
+		if rs.Status == defs.StatusError {
+			rsp.Phase = psmdbv1.RestorePhaseFailed
+		}
+
+		var prevPhase psmdbv1.RestorePhase
+		var prevTime *metav1.Time
+		if prevRS != nil {
+			prevPhase, prevTime = prevRS.Phase, prevRS.LastTransition
+		}
+		rsp.LastTransition = lastTransition(prevPhase, rsp.Phase, prevTime, now)
+
+		rsPhases = append(rsPhases, rsp.Phase)
+		progress.Replsets = append(progress.Replsets, rsp)
+	}
+
+	progress.Phase = psmdbv1.RestorePhaseRestoringData
+	if len(rsPhases) > 0 {
+		progress.Phase = minPhase(rsPhases...)
+	}
+	if meta.Status == defs.StatusError {
+		progress.Phase = psmdbv1.RestorePhaseFailed
+	}
+
+	if prev != nil {
+		progress.LastTransition = lastTransition(prev.Phase, progress.Phase, prev.LastTransition, now)
+	} else {
+		progress.LastTransition = &now
+	}
+
+	return progress
+}
+
+// progressWithPhase returns a copy of the progress with the restore and all its replsets and nodes in the phase.
+func progressWithPhase(prev *psmdbv1.RestoreProgress, phase psmdbv1.RestorePhase, now metav1.Time) *psmdbv1.RestoreProgress {
+	progress := prev.DeepCopy()
+	if progress == nil {
+		progress = &psmdbv1.RestoreProgress{}
+	}
+
+	if progress.Phase != phase {
+		progress.Phase = phase
+		progress.LastTransition = &now
+	}
+	for i := range progress.Replsets {
+		rs := &progress.Replsets[i]
+		if rs.Phase != phase {
+			rs.Phase = phase
+			rs.LastTransition = &now
+		}
+		for j := range rs.Nodes {
+			if rs.Nodes[j].Phase != phase {
+				rs.Nodes[j].Phase = phase
+				rs.Nodes[j].LastTransition = &now
+			}
+		}
+	}
+
+	return progress
+}
+
+// finishingProgress returns the progress of the restarts of the replsets after the data is restored
+// and of the PBM resync after the restarts. It never modifies prev.
+func (r *ReconcilePerconaServerMongoDBRestore) finishingProgress(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, prev *psmdbv1.RestoreProgress) (*psmdbv1.RestoreProgress, error) {
+	now := metav1.Now()
+	progress := prev.DeepCopy()
+
+	restarted := true
+	for i := range progress.Replsets {
+		rs := &progress.Replsets[i]
+		if rs.Phase != psmdbv1.RestorePhaseRestartingNodes {
+			continue
+		}
+
+		ready, err := r.statefulSetRestarted(ctx, cluster, rs.Name)
+		if err != nil {
+			return prev, err
+		}
+		if !ready {
+			restarted = false
+			continue
+		}
+
+		rs.Phase = psmdbv1.RestorePhaseResyncingCluster
+		rs.LastTransition = &now
+		for j := range rs.Nodes {
+			rs.Nodes[j].Phase = psmdbv1.RestorePhaseResyncingCluster
+			rs.Nodes[j].LastTransition = &now
+		}
+	}
+
+	if !restarted {
+		return progress, nil
+	}
+
+	if _, ok := cluster.Annotations[psmdbv1.AnnotationResyncPBM]; ok {
+		return progressWithPhase(progress, psmdbv1.RestorePhaseResyncingCluster, now), nil
+	}
+
+	return progressWithPhase(progress, psmdbv1.RestorePhaseDone, now), nil
+}
+
+// statefulSetRestarted returns true if the statefulset of the replset is recreated without
+// the physical restore changes and all its pods are ready.
+func (r *ReconcilePerconaServerMongoDBRestore) statefulSetRestarted(ctx context.Context, cluster *psmdbv1.PerconaServerMongoDB, rsName string) (bool, error) {
+	sts := appsv1.StatefulSet{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: cluster.Name + "-" + rsName, Namespace: cluster.Namespace}, &sts)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "get statefulset %s", rsName)
+	}
+
+	if _, ok := sts.Annotations[psmdbv1.AnnotationRestoreInProgress]; ok {
+		return false, nil
+	}
+
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+
+	return sts.Status.ObservedGeneration >= sts.Generation && sts.Status.ReadyReplicas >= replicas, nil
+}
+
+// recordProgressEvents emits an event for each phase change of the restore and its replsets.
+func (r *ReconcilePerconaServerMongoDBRestore) recordProgressEvents(cr *psmdbv1.PerconaServerMongoDBRestore, prev, cur *psmdbv1.RestoreProgress) {
+	if r.recorder == nil || cur == nil {
+		return
+	}
+
+	eventType := func(phase psmdbv1.RestorePhase) string {
+		if phase == psmdbv1.RestorePhaseFailed {
+			return corev1.EventTypeWarning
+		}
+		return corev1.EventTypeNormal
+	}
+	reason := func(phase psmdbv1.RestorePhase) string {
+		if phase == "" {
+			return ""
+		}
+		return strings.ToUpper(string(phase[:1])) + string(phase[1:])
+	}
+
+	if prev == nil || prev.Phase != cur.Phase {
+		r.recorder.Eventf(cr, eventType(cur.Phase), reason(cur.Phase), "Restore phase changed to %s", cur.Phase)
+	}
+
+	for _, rs := range cur.Replsets {
+		prevRS := prev.Replset(rs.Name)
+		if prevRS != nil && prevRS.Phase == rs.Phase {
+			continue
+		}
+		if rs.Error != "" {
+			r.recorder.Eventf(cr, eventType(rs.Phase), reason(rs.Phase), "Replset %s phase changed to %s: %s", rs.Name, rs.Phase, rs.Error)
+			continue
+		}
+		r.recorder.Eventf(cr, eventType(rs.Phase), reason(rs.Phase), "Replset %s phase changed to %s", rs.Name, rs.Phase)
+	}
+}
@@ -0,0 +1,185 @@
+package perconaservermongodbrestore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/restore"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+)
+
+func TestRestoreProgress(t *testing.T) {
+	now := metav1.Now()
+	earlier := metav1.NewTime(now.Add(-time.Minute))
+
+	meta := &restore.RestoreMeta{
+		Status: defs.StatusRunning,
+		Replsets: []restore.RestoreReplset{
+			{
+				Name:   "rs0",
+				Status: defs.StatusRunning,
+				Nodes: []restore.RestoreNode{
+					{Name: "cluster-rs0-0.cluster-rs0.ns.svc.cluster.local:27017", Status: defs.StatusRunning},
+					{Name: "cluster-rs0-1.cluster-rs0.ns.svc.cluster.local:27017", Status: defs.StatusRunning},
+				},
+			},
+			{
+				Name:   "cfg",
+				Status: defs.StatusDone,
+				Nodes: []restore.RestoreNode{
+					{Name: "cluster-cfg-0.cluster-cfg.ns.svc.cluster.local:27017", Status: defs.StatusDone},
+				},
+			},
+		},
+	}
+	prev := &psmdbv1.RestoreProgress{
+		Phase:          psmdbv1.RestorePhaseRestoringData,
+		LastTransition: &earlier,
+		Replsets: []psmdbv1.RestoreReplsetProgress{
+			{
+				Name:           "rs0",
+				Phase:          psmdbv1.RestorePhaseRestoringData,
+				LastTransition: &earlier,
+				Nodes: []psmdbv1.RestoreNodeProgress{
+					{
+						Name:           "cluster-rs0-0.cluster-rs0.ns.svc.cluster.local:27017",
+						Phase:          psmdbv1.RestorePhaseRestoringData,
+						LastTransition: &earlier,
+					},
+				},
+			},
+		},
+	}
+
+	progress := restoreProgress(prev, meta, now)
+
+	if progress.Phase != psmdbv1.RestorePhaseRestoringData || !progress.LastTransition.Equal(&earlier) {
+		t.Errorf("unexpected restore phase %s changed at %v", progress.Phase, progress.LastTransition)
+	}
+
+	rs0 := progress.Replset("rs0")
+	if rs0 == nil || rs0.Phase != psmdbv1.RestorePhaseRestoringData || !rs0.LastTransition.Equal(&earlier) {
+		t.Fatalf("unexpected rs0 progress %v", rs0)
+	}
+
+	node := rs0.Node("cluster-rs0-1.cluster-rs0.ns.svc.cluster.local:27017")
+	if node == nil || node.Phase != psmdbv1.RestorePhaseRestoringData || !node.LastTransition.Equal(&now) {
+		t.Errorf("unexpected node progress %v", node)
+	}
+
+	cfg := progress.Replset("cfg")
+	if cfg == nil || cfg.Phase != psmdbv1.RestorePhaseRestartingNodes || !cfg.LastTransition.Equal(&now) {
+		t.Errorf("unexpected cfg progress %v", cfg)
+	}
+
+	if prev.Replsets[0].Nodes[0].Phase != psmdbv1.RestorePhaseRestoringData || len(prev.Replsets) != 1 {
+		t.Error("previous progress is modified")
+	}
+
+	meta.Status = defs.StatusError
+	meta.Replsets[0].Status = defs.StatusError
+	meta.Replsets[0].Error = "node lost"
+	progress = restoreProgress(progress, meta, now)
+	if progress.Phase != psmdbv1.RestorePhaseFailed || progress.Status != string(defs.StatusError) || progress.Replset("rs0").Phase != psmdbv1.RestorePhaseFailed {
+		t.Errorf("expected failed restore, got %s", progress.Phase)
+	}
+}
+
+func TestFinishingProgress(t *testing.T) {
+	ctx := context.Background()
+
+	ns := "finishing"
+	cluster := readDefaultCluster(t, "cluster", ns)
+	cluster.Annotations = map[string]string{psmdbv1.AnnotationResyncPBM: "true"}
+
+	replicas := int32(3)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-rs0", Namespace: ns, Generation: 1},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		Status:     appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 2},
+	}
+
+	progress := progressWithPhase(&psmdbv1.RestoreProgress{
+		Replsets: []psmdbv1.RestoreReplsetProgress{
+			{Name: "rs0", Nodes: []psmdbv1.RestoreNodeProgress{{Name: "cluster-rs0-0:27017"}}},
+		},
+	}, psmdbv1.RestorePhaseRestartingNodes, metav1.Now())
+
+	r := fakeReconciler(cluster.DeepCopy(), sts.DeepCopy())
+	got, err := r.finishingProgress(ctx, cluster, progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Phase != psmdbv1.RestorePhaseRestartingNodes {
+		t.Errorf("expected restarting nodes, got %s", got.Phase)
+	}
+
+	sts.Status.ReadyReplicas = 3
+	r = fakeReconciler(cluster.DeepCopy(), sts.DeepCopy())
+	got, err = r.finishingProgress(ctx, cluster, progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Phase != psmdbv1.RestorePhaseResyncingCluster || got.Replsets[0].Nodes[0].Phase != psmdbv1.RestorePhaseResyncingCluster {
+		t.Errorf("expected resyncing cluster, got %s", got.Phase)
+	}
+
+	cluster.Annotations = nil
+	got, err = r.finishingProgress(ctx, cluster, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Phase != psmdbv1.RestorePhaseDone || got.Finishing() {
+		t.Errorf("expected done, got %s", got.Phase)
+	}
+}
+
+func TestRecordProgressEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := &ReconcilePerconaServerMongoDBRestore{recorder: recorder}
+	cr := readDefaultRestore(t, "restore", "events")
+
+	prev := &psmdbv1.RestoreProgress{
+		Phase: psmdbv1.RestorePhaseRestoringData,
+		Replsets: []psmdbv1.RestoreReplsetProgress{
+			{Name: "rs0", Phase: psmdbv1.RestorePhaseRestoringData},
+			{Name: "rs1", Phase: psmdbv1.RestorePhaseRestoringData},
+		},
+	}
+	cur := prev.DeepCopy()
+	cur.Replsets[0].Phase = psmdbv1.RestorePhaseRestartingNodes
+
+	r.recordProgressEvents(cr, prev, cur)
+
+	cur = cur.DeepCopy()
+	cur.Phase = psmdbv1.RestorePhaseFailed
+	cur.Replsets[1].Phase = psmdbv1.RestorePhaseFailed
+	cur.Replsets[1].Error = "no space left on device"
+
+	r.recordProgressEvents(cr, prev, cur)
+
+	expected := []string{
+		"Normal RestartingNodes Replset rs0 phase changed to restartingNodes",
+		"Warning Failed Restore phase changed to failed",
+		"Normal RestartingNodes Replset rs0 phase changed to restartingNodes",
+		"Warning Failed Replset rs1 phase changed to failed: no space left on device",
+	}
+	for _, e := range expected {
+		select {
+		case event := <-recorder.Events:
+			if !strings.HasPrefix(event, e) {
+				t.Errorf("expected event %q, got %q", e, event)
+			}
+		default:
+			t.Fatalf("expected event %q", e)
+		}
+	}
+}
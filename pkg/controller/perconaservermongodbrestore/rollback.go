@@ -0,0 +1,216 @@
+package perconaservermongodbrestore
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+)
+
+// originalStatefulSetsName returns the name of the config map with the specs
+// of the statefulsets before they are changed for the physical restore.
+func originalStatefulSetsName(cr *psmdbv1.PerconaServerMongoDBRestore) string {
+	return cr.Name + "-original-sts"
+}
+
+// physicalRestoreStatefulSets returns the names of all statefulsets changed for the physical restore.
+func physicalRestoreStatefulSets(cluster *psmdbv1.PerconaServerMongoDB) []string {
+	replsets := cluster.Spec.Replsets
+	if cluster.Spec.Sharding.Enabled {
+		replsets = append(replsets, cluster.Spec.Sharding.ConfigsvrReplSet)
+	}
+
+	names := make([]string, 0, len(replsets))
+	for _, rs := range replsets {
+		names = append(names, cluster.Name+"-"+rs.Name)
+		if rs.NonVoting.Enabled {
+			names = append(names, cluster.Name+"-"+rs.Name+"-nv")
+		}
+		if rs.Arbiter.Enabled {
+			names = append(names, cluster.Name+"-"+rs.Name+"-arbiter")
+		}
+	}
+
+	return names
+}
+
+// saveOriginalStatefulSet saves the spec of the statefulset before it's changed for the physical restore.
+// The spec is saved only once, so it's never overwritten by the changed spec.
+func (r *ReconcilePerconaServerMongoDBRestore) saveOriginalStatefulSet(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore, cluster *psmdbv1.PerconaServerMongoDB, nn types.NamespacedName) error {
+	sts := appsv1.StatefulSet{}
+	if err := r.client.Get(ctx, nn, &sts); err != nil {
+		return errors.Wrapf(err, "get statefulset %s", nn.Name)
+	}
+
+	if _, ok := sts.Annotations[psmdbv1.AnnotationRestoreInProgress]; ok {
+		return nil
+	}
+
+	spec, err := json.Marshal(sts.Spec)
+	if err != nil {
+		return errors.Wrapf(err, "marshal statefulset %s spec", sts.Name)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		cm := corev1.ConfigMap{}
+		err := r.client.Get(ctx, types.NamespacedName{Name: originalStatefulSetsName(cr), Namespace: cr.Namespace}, &cm)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrap(err, "get original statefulsets config map")
+		}
+
+		if k8serrors.IsNotFound(err) {
+			cm = corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      originalStatefulSetsName(cr),
+					Namespace: cr.Namespace,
+					Labels:    naming.ClusterLabels(cluster),
+				},
+				Data: map[string]string{sts.Name: string(spec)},
+			}
+			if err := controllerutil.SetControllerReference(cr, &cm, r.scheme); err != nil {
+				return errors.Wrap(err, "set controller reference")
+			}
+
+			return r.client.Create(ctx, &cm)
+		}
+
+		if _, ok := cm.Data[sts.Name]; ok {
+			return nil
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[sts.Name] = string(spec)
+
+		return r.client.Update(ctx, &cm)
+	})
+}
+
+// reconcileRollback rolls back the statefulsets changed for the failed physical restore
+// and starts the restore again if it has retries left.
+// Errors of the rollback are reported in the rollback status and the rollback is retried,
+// the restore stays in the error state with the reason of the failure.
+func (r *ReconcilePerconaServerMongoDBRestore) reconcileRollback(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore) psmdbv1.PerconaServerMongoDBRestoreStatus {
+	log := logf.FromContext(ctx)
+
+	status := cr.Status
+	rollback := status.Rollback.DeepCopy()
+	if rollback == nil {
+		rollback = new(psmdbv1.RestoreRollbackStatus)
+	}
+	if rollback.State == "" {
+		rollback.State = psmdbv1.RestoreRollbackRunning
+		rollback.Reason = cr.Status.Error
+		rollback.Attempts++
+	}
+	status.Rollback = rollback
+
+	cluster := new(psmdbv1.PerconaServerMongoDB)
+	err := r.client.Get(ctx, types.NamespacedName{Name: cr.Spec.ClusterName, Namespace: cr.Namespace}, cluster)
+	if err == nil {
+		err = r.rollbackStatefulSets(ctx, cr, cluster)
+	}
+	if err != nil {
+		log.Error(err, "failed to roll back statefulsets after failed restore")
+		rollback.Error = err.Error()
+		return status
+	}
+
+	log.Info("Statefulsets are rolled back after failed restore", "reason", rollback.Reason, "attempts", rollback.Attempts)
+	if r.recorder != nil {
+		r.recorder.Eventf(cr, corev1.EventTypeWarning, "RolledBack", "Statefulsets are rolled back after failed restore: %s", rollback.Reason)
+	}
+
+	if rollback.Attempts <= cr.Spec.Retries {
+		log.Info("Retrying restore", "attempt", rollback.Attempts+1, "retries", cr.Spec.Retries)
+		if r.recorder != nil {
+			r.recorder.Eventf(cr, corev1.EventTypeNormal, "Retrying", "Retrying restore, attempt %d of %d", rollback.Attempts+1, cr.Spec.Retries+1)
+		}
+
+		return psmdbv1.PerconaServerMongoDBRestoreStatus{
+			State:    psmdbv1.RestoreStateNew,
+			Rollback: &psmdbv1.RestoreRollbackStatus{Attempts: rollback.Attempts},
+		}
+	}
+
+	now := metav1.Now()
+	rollback.State = psmdbv1.RestoreRollbackRolledBack
+	rollback.Error = ""
+	rollback.CompletedAt = &now
+
+	return status
+}
+
+// rollbackStatefulSets restores the saved specs of the statefulsets changed for the physical restore.
+// Statefulsets without a saved spec are deleted to be recreated by the cluster controller.
+func (r *ReconcilePerconaServerMongoDBRestore) rollbackStatefulSets(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore, cluster *psmdbv1.PerconaServerMongoDB) error {
+	log := logf.FromContext(ctx)
+
+	cm := corev1.ConfigMap{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: originalStatefulSetsName(cr), Namespace: cr.Namespace}, &cm)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrap(err, "get original statefulsets config map")
+	}
+
+	for _, name := range physicalRestoreStatefulSets(cluster) {
+		err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			sts := appsv1.StatefulSet{}
+			err := r.client.Get(ctx, types.NamespacedName{Name: name, Namespace: cluster.Namespace}, &sts)
+			if err != nil {
+				return client.IgnoreNotFound(err)
+			}
+
+			if _, ok := sts.Annotations[psmdbv1.AnnotationRestoreInProgress]; !ok {
+				return nil
+			}
+
+			spec, ok := cm.Data[name]
+			if !ok {
+				log.Info("Deleting statefulset without original spec", "statefulset", name)
+				return client.IgnoreNotFound(r.client.Delete(ctx, &sts))
+			}
+
+			orig := appsv1.StatefulSetSpec{}
+			if err := json.Unmarshal([]byte(spec), &orig); err != nil {
+				return errors.Wrap(err, "unmarshal original spec")
+			}
+			sts.Spec = orig
+			delete(sts.Annotations, psmdbv1.AnnotationRestoreInProgress)
+
+			log.Info("Rolling back statefulset", "statefulset", name)
+
+			return r.client.Update(ctx, &sts)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "roll back statefulset %s", name)
+		}
+	}
+
+	return r.deleteOriginalStatefulSets(ctx, cr)
+}
+
+func (r *ReconcilePerconaServerMongoDBRestore) deleteOriginalStatefulSets(ctx context.Context, cr *psmdbv1.PerconaServerMongoDBRestore) error {
+	cm := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      originalStatefulSetsName(cr),
+			Namespace: cr.Namespace,
+		},
+	}
+	if err := r.client.Delete(ctx, &cm); client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, "delete original statefulsets config map")
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package perconaservermongodbrestore
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+)
+
+func TestRollback(t *testing.T) {
+	ctx := context.Background()
+
+	ns := "rollback"
+	cluster := readDefaultCluster(t, "cluster", ns)
+	cluster.Spec.Sharding.ConfigsvrReplSet.Name = psmdbv1.ConfigReplSetName
+
+	cr := readDefaultRestore(t, "restore", ns)
+	cr.Spec.ClusterName = cluster.Name
+
+	replicas := int32(3)
+	objs := []client.Object{cluster, cr}
+	originals := make(map[string]appsv1.StatefulSetSpec)
+	for _, name := range physicalRestoreStatefulSets(cluster) {
+		sts := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   ns,
+				Annotations: map[string]string{"percona.com/ssl-hash": "hash"},
+			},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Name: "mongod", Command: []string{"/data/db/ps-entry.sh"}},
+							{Name: naming.ContainerBackupAgent},
+						},
+					},
+				},
+			},
+		}
+		originals[name] = sts.Spec
+		objs = append(objs, sts)
+	}
+
+	r := fakeReconciler(objs...)
+
+	if err := r.prepareStatefulSetsForPhysicalRestore(ctx, cr, cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	sts := appsv1.StatefulSet{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: "cluster-rs0", Namespace: ns}, &sts); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sts.Annotations[psmdbv1.AnnotationRestoreInProgress]; !ok || len(sts.Spec.Template.Spec.Containers) != 1 {
+		t.Fatal("statefulset is not prepared for physical restore")
+	}
+
+	// the changed statefulsets must not overwrite the saved ones
+	if err := r.prepareStatefulSetsForPhysicalRestore(ctx, cr, cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	cr.Spec.Retries = 1
+	cr.Status = psmdbv1.PerconaServerMongoDBRestoreStatus{
+		State:    psmdbv1.RestoreStateError,
+		Error:    "restore failed: no space left on device",
+		Progress: &psmdbv1.RestoreProgress{Phase: psmdbv1.RestorePhaseFailed},
+	}
+	if !cr.RollbackPending() {
+		t.Fatal("rollback is not pending")
+	}
+
+	status := r.reconcileRollback(ctx, cr)
+	if status.State != psmdbv1.RestoreStateNew || status.Rollback == nil || status.Rollback.Attempts != 1 {
+		t.Fatalf("expected restore to be retried, got %v", status)
+	}
+
+	for name, spec := range originals {
+		sts := appsv1.StatefulSet{}
+		if err := r.client.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, &sts); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := sts.Annotations[psmdbv1.AnnotationRestoreInProgress]; ok {
+			t.Errorf("statefulset %s is still annotated", name)
+		}
+		if !equality.Semantic.DeepEqual(sts.Spec, spec) {
+			t.Errorf("statefulset %s isn't rolled back: %v", name, sts.Spec)
+		}
+	}
+
+	cm := corev1.ConfigMap{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: originalStatefulSetsName(cr), Namespace: ns}, &cm)
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("expected original statefulsets to be deleted, got %v", err)
+	}
+
+	cr.Status = status
+	cr.Status.State = psmdbv1.RestoreStateError
+	cr.Status.Error = "restore failed again"
+	cr.Status.Progress = &psmdbv1.RestoreProgress{Phase: psmdbv1.RestorePhaseFailed}
+
+	status = r.reconcileRollback(ctx, cr)
+	if status.State != psmdbv1.RestoreStateError || status.Error != "restore failed again" {
+		t.Errorf("expected restore to fail, got state %s: %s", status.State, status.Error)
+	}
+	if status.Rollback.State != psmdbv1.RestoreRollbackRolledBack || status.Rollback.Attempts != 2 || status.Rollback.Reason != "restore failed again" {
+		t.Errorf("unexpected rollback status %v", status.Rollback)
+	}
+
+	cr.Status = status
+	if cr.RollbackPending() {
+		t.Error("rollback is pending after it's finished")
+	}
+}
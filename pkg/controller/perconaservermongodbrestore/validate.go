@@ -2,11 +2,13 @@ package perconaservermongodbrestore
 
 import (
 	"context"
+	"slices"
 
 	"github.com/pkg/errors"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	pbmErrors "github.com/percona/percona-backup-mongodb/pbm/errors"
 
 	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
 	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup"
@@ -32,6 +34,16 @@ func (r *ReconcilePerconaServerMongoDBRestore) validate(ctx context.Context, cr
 		return errors.New("`.spec.selective` field is supported only for logical backups")
 	}
 
+	for _, ns := range cr.Spec.Selective.GetNamespaces() {
+		if !bcp.Status.ContainsNamespace(ns) {
+			return errors.Errorf("namespace %s is not in the backup, backup contains only %v", ns, bcp.Status.Namespaces)
+		}
+	}
+
+	if cr.Spec.PITR != nil && cluster.Spec.Backup.StorageProfile(bcp.Spec.StorageName) != "" {
+		return errors.Errorf("point-in-time recovery is supported only for backups in the main storage, backup is in storage %s", bcp.Spec.StorageName)
+	}
+
 	storage, err := r.getStorage(cr, cluster, bcp.Spec.StorageName)
 	if err != nil {
 		return errors.Wrap(err, "get storage")
@@ -52,5 +64,77 @@ func (r *ReconcilePerconaServerMongoDBRestore) validate(ctx context.Context, cr
 	if err := pbmc.ValidateBackup(ctx, bcp, cfg); err != nil {
 		return errors.Wrap(err, "failed to validate backup")
 	}
+
+	if len(cr.Spec.ReplsetRemapping) > 0 {
+		backupReplsets := bcp.Status.ReplsetNames
+
+		// backups from backupSource are not known by PBM until resync
+		meta, err := pbmc.GetBackupMeta(ctx, bcp.Status.PBMname)
+		if err != nil && !errors.Is(err, pbmErrors.ErrNotFound) {
+			return errors.Wrap(err, "get backup metadata")
+		}
+		if meta != nil && len(meta.Replsets) > 0 {
+			backupReplsets = make([]string, 0, len(meta.Replsets))
+			for _, rs := range meta.Replsets {
+				backupReplsets = append(backupReplsets, rs.Name)
+			}
+		}
+
+		if err := validateReplsetRemapping(cr.Spec.ReplsetRemapping, backupReplsets, cluster); err != nil {
+			return errors.Wrap(err, "invalid replsetRemapping")
+		}
+	}
+
 	return nil
 }
+
+// validateReplsetRemapping checks that the mapping uses replset names of the backup
+// and that every replset of the backup is restored to a replset of the target cluster.
+func validateReplsetRemapping(rsMap map[string]string, backupReplsets []string, cluster *psmdbv1.PerconaServerMongoDB) error {
+	targets := clusterReplsets(cluster)
+
+	if len(backupReplsets) > 0 {
+		for from := range rsMap {
+			if !slices.Contains(backupReplsets, from) {
+				return errors.Errorf("replset %s is not in the backup, backup contains only %v", from, backupReplsets)
+			}
+		}
+	}
+
+	for from, to := range rsMap {
+		if _, ok := targets[to]; !ok {
+			return errors.Errorf("replset %s mapped from %s is not in cluster %s", to, from, cluster.Name)
+		}
+	}
+
+	for _, rs := range backupReplsets {
+		to, ok := rsMap[rs]
+		if !ok {
+			to = rs
+		}
+		if _, ok := targets[to]; !ok {
+			return errors.Errorf("replset %s of the backup is restored to %s, which is not in cluster %s", rs, to, cluster.Name)
+		}
+	}
+
+	return nil
+}
+
+// clusterReplsets returns replsets of the cluster by the names they have in MongoDB.
+func clusterReplsets(cluster *psmdbv1.PerconaServerMongoDB) map[string]*psmdbv1.ReplsetSpec {
+	repls := cluster.Spec.Replsets
+	if cluster.Spec.Sharding.Enabled && cluster.Spec.Sharding.ConfigsvrReplSet != nil {
+		repls = append([]*psmdbv1.ReplsetSpec{cluster.Spec.Sharding.ConfigsvrReplSet}, repls...)
+	}
+
+	replsets := make(map[string]*psmdbv1.ReplsetSpec, len(repls))
+	for _, rs := range repls {
+		name := rs.Name
+		if n, err := rs.CustomReplsetName(); err == nil {
+			name = n
+		}
+		replsets[name] = rs
+	}
+
+	return replsets
+}
@@ -7,9 +7,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+
 	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
 	fakeBackup "github.com/percona/percona-server-mongodb-operator/pkg/psmdb/backup/fake"
 )
@@ -137,6 +140,83 @@ func TestValidate(t *testing.T) {
 			[]client.Object{bcp.DeepCopy(), secret.DeepCopy()},
 			"get storage: unable to get storage 'validate-stg'",
 		},
+		{
+			"pitr from non-main storage",
+			updateObj(t, cr.DeepCopy(), func(cr *psmdbv1.PerconaServerMongoDBRestore) {
+				cr.Spec.PITR = &psmdbv1.PITRestoreSpec{
+					Type: psmdbv1.PITRestoreTypeLatest,
+				}
+			}),
+			updateObj(t, cluster.DeepCopy(), func(cluster *psmdbv1.PerconaServerMongoDB) {
+				cluster.Spec.Backup.Storages["main-stg"] = psmdbv1.BackupStorageSpec{
+					Main: true,
+					Type: psmdbv1.BackupStorageFilesystem,
+					Filesystem: psmdbv1.BackupStorageFilesystemSpec{
+						Path: "/mnt/backups",
+					},
+				}
+			}),
+			[]client.Object{bcp.DeepCopy(), secret.DeepCopy()},
+			"point-in-time recovery is supported only for backups in the main storage, backup is in storage validate-stg",
+		},
+		{
+			"namespace is not in selective backup",
+			updateObj(t, cr.DeepCopy(), func(cr *psmdbv1.PerconaServerMongoDBRestore) {
+				cr.Spec.Selective = &psmdbv1.SelectiveRestoreOpts{
+					Namespaces: []string{"archive.*"},
+				}
+			}),
+			cluster.DeepCopy(),
+			[]client.Object{updateObj(t, bcp.DeepCopy(), func(bcp *psmdbv1.PerconaServerMongoDBBackup) {
+				bcp.Status.Type = defs.LogicalBackup
+				bcp.Status.Namespaces = []string{"app.*"}
+			}), secret.DeepCopy()},
+			"namespace archive.* is not in the backup, backup contains only [app.*]",
+		},
+		{
+			"replset remapping",
+			updateObj(t, cr.DeepCopy(), func(cr *psmdbv1.PerconaServerMongoDBRestore) {
+				cr.Spec.ReplsetRemapping = map[string]string{"prod-rs0": "rs0"}
+			}),
+			cluster.DeepCopy(),
+			[]client.Object{updateObj(t, bcp.DeepCopy(), func(bcp *psmdbv1.PerconaServerMongoDBBackup) {
+				bcp.Status.ReplsetNames = []string{"prod-rs0"}
+			}), secret.DeepCopy()},
+			"",
+		},
+		{
+			"replset remapping to unknown replset",
+			updateObj(t, cr.DeepCopy(), func(cr *psmdbv1.PerconaServerMongoDBRestore) {
+				cr.Spec.ReplsetRemapping = map[string]string{"prod-rs0": "rs1"}
+			}),
+			cluster.DeepCopy(),
+			[]client.Object{updateObj(t, bcp.DeepCopy(), func(bcp *psmdbv1.PerconaServerMongoDBBackup) {
+				bcp.Status.ReplsetNames = []string{"prod-rs0"}
+			}), secret.DeepCopy()},
+			"invalid replsetRemapping: replset rs1 mapped from prod-rs0 is not in cluster validate-cr",
+		},
+		{
+			"replset remapping from unknown replset",
+			updateObj(t, cr.DeepCopy(), func(cr *psmdbv1.PerconaServerMongoDBRestore) {
+				cr.Spec.ReplsetRemapping = map[string]string{"rs1": "rs0"}
+			}),
+			cluster.DeepCopy(),
+			[]client.Object{updateObj(t, bcp.DeepCopy(), func(bcp *psmdbv1.PerconaServerMongoDBBackup) {
+				bcp.Status.ReplsetNames = []string{"prod-rs0"}
+			}), secret.DeepCopy()},
+			"invalid replsetRemapping: replset rs1 is not in the backup, backup contains only [prod-rs0]",
+		},
+		{
+			"backup replset without mapping",
+			updateObj(t, cr.DeepCopy(), func(cr *psmdbv1.PerconaServerMongoDBRestore) {
+				cr.Spec.ReplsetRemapping = map[string]string{"prod-rs0": "rs0"}
+			}),
+			cluster.DeepCopy(),
+			[]client.Object{updateObj(t, bcp.DeepCopy(), func(bcp *psmdbv1.PerconaServerMongoDBBackup) {
+				bcp.Status.ReplsetNames = []string{"prod-rs0", "prod-rs1"}
+			}), secret.DeepCopy()},
+			"invalid replsetRemapping: replset prod-rs1 of the backup is restored to prod-rs1, which is not in cluster validate-cr",
+		},
 	}
 
 	for _, tt := range tests {
@@ -168,6 +248,9 @@ func fakeReconciler(objs ...client.Object) *ReconcilePerconaServerMongoDBRestore
 	return &ReconcilePerconaServerMongoDBRestore{
 		client:     cl,
 		scheme:     s,
+		recorder:   record.NewFakeRecorder(100),
 		newPBMFunc: fakeBackup.NewPBM,
+
+		newStoragePBMFunc: fakeBackup.NewPBM,
 	}
 }
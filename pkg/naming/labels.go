@@ -20,6 +20,12 @@ const (
 	LabelBackupAncestor = perconaPrefix + "backup-ancestor"
 	LabelBackupType     = perconaPrefix + "backup-type"
 	LabelCluster        = perconaPrefix + "cluster"
+
+	// BackupTypeImported is the value of LabelBackupType for backups imported from storage
+	BackupTypeImported = "imported"
+
+	// ComponentBackupStorage is the value of LabelKubernetesComponent for volumes of filesystem backup storages
+	ComponentBackupStorage = "backup-storage"
 )
 
 func ClusterLabels(cr *api.PerconaServerMongoDB) map[string]string {
@@ -90,6 +96,22 @@ func ScheduledBackupLabels(cr *api.PerconaServerMongoDB, task *api.BackupTaskSpe
 	return ls
 }
 
+func ImportedBackupLabels(cr *api.PerconaServerMongoDB) map[string]string {
+	ls := ClusterLabels(cr)
+	ls[LabelCluster] = cr.Name
+	ls[LabelBackupType] = BackupTypeImported
+
+	return ls
+}
+
+// BackupStorageLabels returns labels of volumes provisioned for filesystem backup storages
+func BackupStorageLabels(cr *api.PerconaServerMongoDB) map[string]string {
+	ls := ClusterLabels(cr)
+	ls[LabelKubernetesComponent] = ComponentBackupStorage
+
+	return ls
+}
+
 func NewBackupCronJobLabels(cr *api.PerconaServerMongoDB, labels map[string]string) map[string]string {
 	ls := ClusterLabels(cr)
 	ls[LabelKubernetesReplset] = "general"
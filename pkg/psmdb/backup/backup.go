@@ -44,6 +44,40 @@ func NewRestoreJob(cr *api.PerconaServerMongoDBRestore) Job {
 	return j
 }
 
+// Destination returns the full path of the backup with the given PBM name in the storage.
+func Destination(stg api.BackupStorageSpec, pbmName string) string {
+	var dest string
+
+	switch stg.Type {
+	case api.BackupStorageS3:
+		dest = stg.S3.Bucket
+
+		if stg.S3.Prefix != "" {
+			dest = stg.S3.Bucket + "/" + stg.S3.Prefix
+		}
+		if !strings.HasPrefix(stg.S3.Bucket, "s3://") {
+			dest = "s3://" + dest
+		}
+	case api.BackupStorageAzure:
+		dest = stg.Azure.Container
+
+		if stg.Azure.Prefix != "" {
+			dest = stg.Azure.Container + "/" + stg.Azure.Prefix
+		}
+		if !strings.HasPrefix(stg.Azure.Container, "azure://") {
+			if stg.Azure.EndpointURL != "" {
+				dest = stg.Azure.EndpointURL + "/" + dest
+			} else {
+				dest = "azure://" + dest
+			}
+		}
+	case api.BackupStorageFilesystem:
+		dest = strings.TrimSuffix(stg.Filesystem.Path, "/")
+	}
+
+	return dest + "/" + pbmName
+}
+
 func IsPBMNotConfiguredError(err error) bool {
 	return strings.Contains(err.Error(), "mongo: no documents in result")
 }
@@ -92,6 +126,7 @@ func HasActiveJobs(ctx context.Context, newPBMFunc NewPBMFunc, cl client.Client,
 			continue
 		}
 		if r.Spec.ClusterName == cluster.Name &&
+			!r.Spec.DryRun &&
 			r.Status.State != api.RestoreStateReady &&
 			r.Status.State != api.RestoreStateError &&
 			r.Status.State != api.RestoreStateWaiting {
@@ -73,6 +73,10 @@ func (p *fakePBM) GetRestoreMeta(ctx context.Context, name string) (*restore.Res
 	return nil, nil
 }
 
+func (p *fakePBM) GetPhysRestoreMeta(ctx context.Context, stg *config.StorageConf, name string) (*restore.RestoreMeta, error) {
+	return nil, nil
+}
+
 func (p *fakePBM) GetBackupMeta(ctx context.Context, bcpName string) (*backup.BackupMeta, error) {
 	return nil, nil
 }
@@ -81,6 +85,14 @@ func (p *fakePBM) DeleteBackup(ctx context.Context, name string) error {
 	return nil
 }
 
+func (p *fakePBM) GetNSetProfile(ctx context.Context, k8sclient client.Client, cluster *api.PerconaServerMongoDB, name string, stg api.BackupStorageSpec) error {
+	return nil
+}
+
+func (p *fakePBM) ResyncProfile(ctx context.Context, name string) error {
+	return nil
+}
+
 func (p *fakePBM) GetNSetConfig(ctx context.Context, k8sclient client.Client, cluster *api.PerconaServerMongoDB, stg api.BackupStorageSpec) error {
 	return nil
 }
@@ -109,10 +121,30 @@ func (p *fakePBM) Node(ctx context.Context) (string, error) {
 	return "", nil
 }
 
+func (p *fakePBM) GetFCV(ctx context.Context) (string, error) {
+	return "", nil
+}
+
 func (p *fakePBM) ValidateBackup(ctx context.Context, bcp *psmdbv1.PerconaServerMongoDBBackup, cfg config.Config) error {
 	return nil
 }
 
+func (p *fakePBM) GetReplsetDataSizes(ctx context.Context, stg *config.StorageConf, name string) (map[string]int64, error) {
+	return nil, nil
+}
+
 func (p *fakePBM) DeletePITRChunks(ctx context.Context, until primitive.Timestamp) error {
 	return nil
 }
+
+func (p *fakePBM) DeletePITRChunksBefore(ctx context.Context, ts primitive.Timestamp) error {
+	return nil
+}
+
+func (p *fakePBM) ReleaseLocks(ctx context.Context, opid string) error {
+	return nil
+}
+
+func (p *fakePBM) GetDoneBackups(ctx context.Context) ([]backup.BackupMeta, error) {
+	return nil, nil
+}
@@ -109,6 +109,10 @@ func BackupFromTask(cr *api.PerconaServerMongoDB, task *api.BackupTaskSpec) (*ap
 			StorageName:      task.StorageName,
 			Compression:      task.CompressionType,
 			CompressionLevel: task.CompressionLevel,
+			IncrementalBase:  task.IncrementalBase,
+			Verify:           task.Verify,
+			Hooks:            task.Hooks,
+			Namespaces:       task.Namespaces,
 		},
 	}
 	if err := backupCr.CheckFields(); err != nil {
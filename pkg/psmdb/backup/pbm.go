@@ -22,6 +22,7 @@ import (
 	"github.com/percona/percona-backup-mongodb/pbm/config"
 	"github.com/percona/percona-backup-mongodb/pbm/connect"
 	"github.com/percona/percona-backup-mongodb/pbm/ctrl"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
 	"github.com/percona/percona-backup-mongodb/pbm/lock"
 	pbmLog "github.com/percona/percona-backup-mongodb/pbm/log"
 	"github.com/percona/percona-backup-mongodb/pbm/oplog"
@@ -33,6 +34,7 @@ import (
 	"github.com/percona/percona-backup-mongodb/pbm/storage/s3"
 	"github.com/percona/percona-backup-mongodb/pbm/topo"
 	"github.com/percona/percona-backup-mongodb/pbm/util"
+	"github.com/percona/percona-backup-mongodb/pbm/version"
 
 	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
 	psmdbv1 "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
@@ -73,13 +75,18 @@ type PBM interface {
 	Close(ctx context.Context) error
 	HasLocks(ctx context.Context, predicates ...LockHeaderPredicate) (bool, error)
 	ValidateBackup(ctx context.Context, bcp *psmdbv1.PerconaServerMongoDBBackup, cfg config.Config) error
+	GetReplsetDataSizes(ctx context.Context, stg *config.StorageConf, name string) (map[string]int64, error)
 
 	GetBackupMeta(ctx context.Context, bcpName string) (*backup.BackupMeta, error)
+	GetDoneBackups(ctx context.Context) ([]backup.BackupMeta, error)
 	GetRestoreMeta(ctx context.Context, name string) (*restore.RestoreMeta, error)
+	GetPhysRestoreMeta(ctx context.Context, stg *config.StorageConf, name string) (*restore.RestoreMeta, error)
 
 	DeleteBackup(ctx context.Context, name string) error
 
 	GetNSetConfig(ctx context.Context, k8sclient client.Client, cluster *api.PerconaServerMongoDB, stg api.BackupStorageSpec) error
+	GetNSetProfile(ctx context.Context, k8sclient client.Client, cluster *api.PerconaServerMongoDB, name string, stg api.BackupStorageSpec) error
+	ResyncProfile(ctx context.Context, name string) error
 	SetConfig(ctx context.Context, cfg *config.Config) error
 	SetConfigVar(ctx context.Context, key, val string) error
 
@@ -87,8 +94,11 @@ type PBM interface {
 	GetConfigVar(ctx context.Context, key string) (any, error)
 
 	DeletePITRChunks(ctx context.Context, until primitive.Timestamp) error
+	DeletePITRChunksBefore(ctx context.Context, ts primitive.Timestamp) error
 
 	Node(ctx context.Context) (string, error)
+	GetFCV(ctx context.Context) (string, error)
+	ReleaseLocks(ctx context.Context, opid string) error
 }
 
 func getMongoUri(ctx context.Context, k8sclient client.Client, cr *api.PerconaServerMongoDB, addrs []string, tlsEnabled bool) (string, error) {
@@ -189,24 +199,29 @@ func NewPBM(ctx context.Context, c client.Client, cluster *api.PerconaServerMong
 	}, nil
 }
 
+// NewStoragePBM creates PBM without a connection to the cluster.
+// It can be used only to read from the storages passed to its methods,
+// e.g. to get the physical restore metadata while mongod is stopped.
+func NewStoragePBM(_ context.Context, c client.Client, cluster *api.PerconaServerMongoDB) (PBM, error) {
+	return &pbmC{
+		pbmLogger: pbmLog.DiscardLogger,
+		k8c:       c,
+		namespace: cluster.Namespace,
+	}, nil
+}
+
 // GetPriorities returns priorities to be used in PBM config.
 func GetPriorities(ctx context.Context, k8sclient client.Client, cluster *api.PerconaServerMongoDB) (map[string]float64, error) {
 	log := logf.FromContext(ctx)
 	priorities := make(map[string]float64)
 
-	usersSecret := corev1.Secret{}
-	err := k8sclient.Get(
-		ctx,
-		types.NamespacedName{Name: cluster.Spec.Secrets.Users, Namespace: cluster.Namespace},
-		&usersSecret,
-	)
-	if err != nil {
-		return priorities, errors.Wrap(err, "get users secret")
+	if opts := cluster.Spec.Backup.Configuration.BackupOptions; opts != nil && len(opts.PriorityRules) > 0 {
+		return getRulesPriorities(ctx, k8sclient, cluster, opts.PriorityRules)
 	}
 
-	c := psmdb.Credentials{
-		Username: string(usersSecret.Data["MONGODB_BACKUP_USER"]),
-		Password: string(usersSecret.Data["MONGODB_BACKUP_PASSWORD"]),
+	c, err := backupCredentials(ctx, k8sclient, cluster)
+	if err != nil {
+		return priorities, err
 	}
 
 	for _, rs := range cluster.Spec.Replsets {
@@ -240,6 +255,23 @@ func GetPriorities(ctx context.Context, k8sclient client.Client, cluster *api.Pe
 	return priorities, nil
 }
 
+func backupCredentials(ctx context.Context, k8sclient client.Client, cluster *api.PerconaServerMongoDB) (psmdb.Credentials, error) {
+	usersSecret := corev1.Secret{}
+	err := k8sclient.Get(
+		ctx,
+		types.NamespacedName{Name: cluster.Spec.Secrets.Users, Namespace: cluster.Namespace},
+		&usersSecret,
+	)
+	if err != nil {
+		return psmdb.Credentials{}, errors.Wrap(err, "get users secret")
+	}
+
+	return psmdb.Credentials{
+		Username: string(usersSecret.Data["MONGODB_BACKUP_USER"]),
+		Password: string(usersSecret.Data["MONGODB_BACKUP_PASSWORD"]),
+	}, nil
+}
+
 func GetPBMConfig(ctx context.Context, k8sclient client.Client, cluster *api.PerconaServerMongoDB, stg api.BackupStorageSpec) (config.Config, error) {
 	conf := config.Config{
 		PITR: &config.PITRConf{
@@ -252,9 +284,12 @@ func GetPBMConfig(ctx context.Context, k8sclient client.Client, cluster *api.Per
 	if cluster.Spec.Backup.Configuration.BackupOptions != nil {
 		conf.Backup = &config.BackupConf{
 			OplogSpanMin: cluster.Spec.Backup.Configuration.BackupOptions.OplogSpanMin,
-			Timeouts: &config.BackupTimeouts{
+		}
+
+		if cluster.Spec.Backup.Configuration.BackupOptions.Timeouts != nil {
+			conf.Backup.Timeouts = &config.BackupTimeouts{
 				Starting: cluster.Spec.Backup.Configuration.BackupOptions.Timeouts.Starting,
-			},
+			}
 		}
 
 		if cluster.Spec.Backup.Configuration.BackupOptions.Priority != nil {
@@ -403,18 +438,116 @@ func (b *pbmC) ValidateBackup(ctx context.Context, bcp *psmdbv1.PerconaServerMon
 	}
 
 	backupName := bcp.Status.PBMname
-	m, err := restore.GetMetaFromStore(stg, backupName)
+	chain, err := BackupChain(backupName, func(name string) (*backup.BackupMeta, error) {
+		return restore.GetMetaFromStore(stg, name)
+	})
 	if err != nil {
 		return errors.Wrap(err, "get backup metadata from storage")
 	}
 
-	if err := backup.CheckBackupFiles(ctx, stg, m.Name); err != nil {
-		return errors.Wrap(err, "check backup files")
+	for _, m := range chain {
+		if err := backup.CheckBackupFiles(ctx, stg, m.Name); err != nil {
+			return errors.Wrapf(err, "check backup files of %s", m.Name)
+		}
 	}
 
 	return nil
 }
 
+// BackupChain returns metadata of all backups needed to restore the backup
+// with the given name, starting from the base of the incremental chain.
+// For a non-incremental backup it returns only its own metadata.
+func BackupChain(name string, getMeta func(name string) (*backup.BackupMeta, error)) ([]*backup.BackupMeta, error) {
+	var chain []*backup.BackupMeta
+	seen := make(map[string]struct{})
+
+	for name != "" {
+		if _, ok := seen[name]; ok {
+			return nil, errors.Errorf("incremental backup chain has a cycle on %s", name)
+		}
+		seen[name] = struct{}{}
+
+		m, err := getMeta(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get metadata of %s", name)
+		}
+		if len(chain) > 0 {
+			if m.Type != defs.IncrementalBackup {
+				return nil, errors.Errorf("backup %s is a source of incremental backup but it is not incremental", m.Name)
+			}
+			if m.Status != defs.StatusDone {
+				return nil, errors.Errorf("backup %s of the incremental chain is in %s state", m.Name, m.Status)
+			}
+		}
+		chain = append(chain, m)
+
+		if m.Type != defs.IncrementalBackup {
+			break
+		}
+		name = m.SrcBackup
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+
+	return chain, nil
+}
+
+// GetReplsetDataSizes reads the lists of backup files from the given storage
+// and returns the size of the data files of each replset after the backup is restored.
+func (b *pbmC) GetReplsetDataSizes(ctx context.Context, stg *config.StorageConf, name string) (map[string]int64, error) {
+	e := b.Logger().NewEvent(string(ctrl.CmdRestore), name, "", primitive.Timestamp{})
+	s, err := util.StorageFromConfig(stg, e)
+	if err != nil {
+		return nil, errors.Wrap(err, "storage from config")
+	}
+
+	return ReplsetDataSizes(s, name)
+}
+
+// ReplsetDataSizes returns the size of the data files of each replset after the physical
+// or incremental backup with the given name is restored. Since PBM 2.4.1 the lists of files
+// are kept in the storage instead of the metadata. Every backup of an incremental chain
+// lists the files it changed, the restored file has the largest size listed in the chain.
+func ReplsetDataSizes(stg storage.Storage, name string) (map[string]int64, error) {
+	chain, err := BackupChain(name, func(name string) (*backup.BackupMeta, error) {
+		return restore.GetMetaFromStore(stg, name)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "get backup metadata from storage")
+	}
+
+	fileSizes := make(map[string]map[string]int64)
+	for _, m := range chain {
+		for _, rs := range m.Replsets {
+			files := rs.Files
+			if len(files) == 0 {
+				files, err = backup.ReadFilelistForReplset(stg, m.Name, rs.Name)
+				if err != nil {
+					return nil, errors.Wrapf(err, "read file list of %s", m.Name)
+				}
+			}
+
+			if fileSizes[rs.Name] == nil {
+				fileSizes[rs.Name] = make(map[string]int64)
+			}
+			for _, f := range files {
+				fileSizes[rs.Name][f.Name] = max(fileSizes[rs.Name][f.Name], f.Size)
+			}
+		}
+	}
+
+	sizes := make(map[string]int64, len(fileSizes))
+	for rs, files := range fileSizes {
+		for _, size := range files {
+			sizes[rs] += size
+		}
+	}
+
+	return sizes, nil
+}
+
 func (b *pbmC) Conn() *mongo.Client {
 	return b.Client.MongoClient()
 }
@@ -438,6 +571,43 @@ func (b *pbmC) GetNSetConfig(ctx context.Context, k8sclient client.Client, clust
 	return nil
 }
 
+// GetNSetProfile saves PBM config profile with the given name
+// for the storage defined in the cluster CR
+func (b *pbmC) GetNSetProfile(ctx context.Context, k8sclient client.Client, cluster *api.PerconaServerMongoDB, name string, stg api.BackupStorageSpec) error {
+	log := logf.FromContext(ctx)
+	log.Info("Setting PBM config profile", "cluster", cluster.Name, "profile", name)
+
+	conf, err := GetPBMConfig(ctx, k8sclient, cluster, stg)
+	if err != nil {
+		return errors.Wrap(err, "get PBM config")
+	}
+
+	profile := &config.Config{
+		Name:      name,
+		IsProfile: true,
+		Storage:   conf.Storage,
+	}
+	if err := config.AddProfile(ctx, b.Client, profile); err != nil {
+		return errors.Wrapf(err, "add config profile %s", name)
+	}
+
+	return nil
+}
+
+// ResyncProfile syncs backup list from the storage of PBM config profile
+func (b *pbmC) ResyncProfile(ctx context.Context, name string) error {
+	profile, err := config.GetProfile(ctx, b.Client, name)
+	if err != nil {
+		return errors.Wrapf(err, "get config profile %s", name)
+	}
+
+	if err := resync.SyncBackupList(ctx, b.Client, &profile.Storage, name); err != nil {
+		return errors.Wrapf(err, "sync backup list from profile %s", name)
+	}
+
+	return nil
+}
+
 func (b *pbmC) SetConfig(ctx context.Context, cfg *config.Config) error {
 	err := config.SetConfig(ctx, b.Client, cfg)
 	return errors.Wrap(err, "set config")
@@ -445,6 +615,9 @@ func (b *pbmC) SetConfig(ctx context.Context, cfg *config.Config) error {
 
 // Close close the PBM connection
 func (b *pbmC) Close(ctx context.Context) error {
+	if b.Client == nil {
+		return nil
+	}
 	return b.Client.Disconnect(ctx)
 }
 
@@ -473,6 +646,10 @@ func IsPITRLock(l lock.LockHeader) bool {
 	return l.Type == ctrl.CmdPITR
 }
 
+func IsResyncLock(l lock.LockHeader) bool {
+	return l.Type == ctrl.CmdResync
+}
+
 func NotJobLock(j Job) LockHeaderPredicate {
 	return func(h lock.LockHeader) bool {
 		var jobCommand ctrl.Command
@@ -593,9 +770,9 @@ func (b *pbmC) pitrGetChunkContains(ctx context.Context, rs string, ts primitive
 	res := b.Client.PITRChunksCollection().FindOne(
 		ctx,
 		bson.D{
-			{"rs", rs},
-			{"start_ts", bson.M{"$lte": ts}},
-			{"end_ts", bson.M{"$gte": ts}},
+			{Key: "rs", Value: rs},
+			{Key: "start_ts", Value: bson.M{"$lte": ts}},
+			{Key: "end_ts", Value: bson.M{"$gte": ts}},
 		},
 	)
 	if res.Err() != nil {
@@ -642,6 +819,11 @@ func (b *pbmC) Node(ctx context.Context) (string, error) {
 	return lock.Node, nil
 }
 
+// GetFCV returns the feature compatibility version of the cluster.
+func (b *pbmC) GetFCV(ctx context.Context) (string, error) {
+	return version.GetFCV(ctx, b.Client.MongoClient())
+}
+
 func (b *pbmC) GetStorage(ctx context.Context, e pbmLog.LogEvent) (storage.Storage, error) {
 	return util.GetStorage(ctx, b.Client, e)
 }
@@ -658,10 +840,29 @@ func (b *pbmC) SetConfigVar(ctx context.Context, key, val string) error {
 	return config.SetConfigVar(ctx, b.Client, key, val)
 }
 
+// ReleaseLocks deletes locks of the operation with the given OPID
+// regardless of their heartbeats. It should be used only for operations
+// that are canceled and didn't release their locks in time.
+func (b *pbmC) ReleaseLocks(ctx context.Context, opid string) error {
+	filter := bson.D{{Key: "opid", Value: opid}}
+	for _, coll := range []*mongo.Collection{b.LockCollection(), b.LockOpCollection()} {
+		if _, err := coll.DeleteMany(ctx, filter); err != nil {
+			return errors.Wrapf(err, "delete locks from %s", coll.Name())
+		}
+	}
+
+	return nil
+}
+
 func (b *pbmC) GetBackupMeta(ctx context.Context, bcpName string) (*backup.BackupMeta, error) {
 	return backup.NewDBManager(b.Client).GetBackupByName(ctx, bcpName)
 }
 
+// GetDoneBackups returns metadata of all completed backups PBM knows about
+func (b *pbmC) GetDoneBackups(ctx context.Context) ([]backup.BackupMeta, error) {
+	return backup.BackupsDoneList(ctx, b.Client, nil, 0, 1)
+}
+
 func (b *pbmC) DeleteBackup(ctx context.Context, name string) error {
 	return backup.DeleteBackup(ctx, b.Client, name)
 }
@@ -670,6 +871,18 @@ func (b *pbmC) GetRestoreMeta(ctx context.Context, name string) (*restore.Restor
 	return restore.GetRestoreMeta(ctx, b.Client, name)
 }
 
+// GetPhysRestoreMeta reads metadata of the physical restore from the given storage.
+// It doesn't use the PBM connection since mongod is stopped during physical restores.
+func (b *pbmC) GetPhysRestoreMeta(ctx context.Context, stg *config.StorageConf, name string) (*restore.RestoreMeta, error) {
+	e := b.Logger().NewEvent(string(ctrl.CmdRestore), name, "", primitive.Timestamp{})
+	s, err := util.StorageFromConfig(stg, e)
+	if err != nil {
+		return nil, errors.Wrap(err, "storage from config")
+	}
+
+	return restore.GetPhysRestoreMeta(name, s, e)
+}
+
 func (b *pbmC) ResyncStorage(ctx context.Context, stg *config.StorageConf) error {
 	return resync.Resync(ctx, b.Client, stg)
 }
@@ -685,21 +898,44 @@ func (b *pbmC) PITRChunksCollection() *mongo.Collection {
 }
 
 func (b *pbmC) DeletePITRChunks(ctx context.Context, until primitive.Timestamp) error {
-	e := b.Logger().NewEvent(string(ctrl.CmdDeletePITR), "", "", primitive.Timestamp{})
-
-	stg, err := b.GetStorage(ctx, e)
+	chunks, err := b.PITRGetChunksSlice(ctx, "", primitive.Timestamp{}, until)
 	if err != nil {
-		return errors.Wrap(err, "get storage")
+		return errors.Wrap(err, "get pitr chunks")
 	}
 
-	chunks, err := b.PITRGetChunksSlice(ctx, "", primitive.Timestamp{}, until)
+	return b.deletePITRChunks(ctx, chunks)
+}
+
+// DeletePITRChunksBefore deletes PITR oplog chunks that end before the given timestamp.
+// Unlike DeletePITRChunks, it keeps the chunk that contains the timestamp.
+func (b *pbmC) DeletePITRChunksBefore(ctx context.Context, ts primitive.Timestamp) error {
+	chunks, err := b.PITRGetChunksSlice(ctx, "", primitive.Timestamp{}, ts)
 	if err != nil {
 		return errors.Wrap(err, "get pitr chunks")
 	}
+
+	outdated := make([]oplog.OplogChunk, 0, len(chunks))
+	for _, chnk := range chunks {
+		if chnk.EndTS.Before(ts) {
+			outdated = append(outdated, chnk)
+		}
+	}
+
+	return b.deletePITRChunks(ctx, outdated)
+}
+
+func (b *pbmC) deletePITRChunks(ctx context.Context, chunks []oplog.OplogChunk) error {
 	if len(chunks) == 0 {
 		return nil
 	}
 
+	e := b.Logger().NewEvent(string(ctrl.CmdDeletePITR), "", "", primitive.Timestamp{})
+
+	stg, err := b.GetStorage(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "get storage")
+	}
+
 	for _, chnk := range chunks {
 		err = stg.Delete(chnk.FName)
 		if err != nil && err != storage.ErrNotExist {
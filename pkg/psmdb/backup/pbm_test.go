@@ -1,10 +1,15 @@
 package backup
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"os"
+	"path"
+	"reflect"
 	"testing"
 
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	// "k8s.io/apimachinery/pkg/runtime/schema"
@@ -13,6 +18,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake" // nolint
 	"sigs.k8s.io/yaml"
 
+	"github.com/percona/percona-backup-mongodb/pbm/backup"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/storage/fs"
+
 	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
 )
 
@@ -116,3 +125,139 @@ func expectedCR(t *testing.T) *api.PerconaServerMongoDB {
 
 	return cr
 }
+
+func TestBackupChain(t *testing.T) {
+	metas := map[string]*backup.BackupMeta{
+		"full":   {Name: "full", Type: defs.PhysicalBackup, Status: defs.StatusDone},
+		"base":   {Name: "base", Type: defs.IncrementalBackup, Status: defs.StatusDone},
+		"inc1":   {Name: "inc1", Type: defs.IncrementalBackup, Status: defs.StatusDone, SrcBackup: "base"},
+		"inc2":   {Name: "inc2", Type: defs.IncrementalBackup, Status: defs.StatusDone, SrcBackup: "inc1"},
+		"failed": {Name: "failed", Type: defs.IncrementalBackup, Status: defs.StatusError, SrcBackup: "inc2"},
+		"inc3":   {Name: "inc3", Type: defs.IncrementalBackup, Status: defs.StatusDone, SrcBackup: "failed"},
+		"orphan": {Name: "orphan", Type: defs.IncrementalBackup, Status: defs.StatusDone, SrcBackup: "missing"},
+		"cycle1": {Name: "cycle1", Type: defs.IncrementalBackup, Status: defs.StatusDone, SrcBackup: "cycle2"},
+		"cycle2": {Name: "cycle2", Type: defs.IncrementalBackup, Status: defs.StatusDone, SrcBackup: "cycle1"},
+	}
+	getMeta := func(name string) (*backup.BackupMeta, error) {
+		m, ok := metas[name]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return m, nil
+	}
+
+	tests := []struct {
+		name     string
+		expected []string
+		err      bool
+	}{
+		{name: "full", expected: []string{"full"}},
+		{name: "base", expected: []string{"base"}},
+		{name: "inc2", expected: []string{"base", "inc1", "inc2"}},
+		{name: "inc3", err: true},
+		{name: "orphan", err: true},
+		{name: "cycle1", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := BackupChain(tt.name, getMeta)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := make([]string, 0, len(chain))
+			for _, m := range chain {
+				names = append(names, m.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestReplsetDataSizes(t *testing.T) {
+	stg, err := fs.New(&fs.Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	save := func(name string, b []byte) {
+		if err := stg.Save(name, bytes.NewReader(b), int64(len(b))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// saveBackup saves the metadata with empty lists of files, as PBM does since 2.4.1,
+	// and the lists of files of every replset
+	saveBackup := func(meta *backup.BackupMeta, files map[string]backup.Filelist) {
+		for rs, list := range files {
+			meta.Replsets = append(meta.Replsets, backup.BackupReplset{Name: rs})
+
+			buf := new(bytes.Buffer)
+			if _, err := list.WriteTo(buf); err != nil {
+				t.Fatal(err)
+			}
+			save(path.Join(meta.Name, rs, backup.FilelistName), buf.Bytes())
+		}
+
+		b, err := json.Marshal(meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		save(meta.Name+defs.MetadataFileSuffix, b)
+	}
+
+	saveBackup(&backup.BackupMeta{Name: "full", Type: defs.PhysicalBackup, Status: defs.StatusDone}, map[string]backup.Filelist{
+		"rs0": {{Name: "collection-1.wt", Size: 100}, {Name: "index-1.wt", Size: 20}},
+		"rs1": {{Name: "collection-1.wt", Size: 50}},
+	})
+	saveBackup(&backup.BackupMeta{Name: "base", Type: defs.IncrementalBackup, Status: defs.StatusDone}, map[string]backup.Filelist{
+		"rs0": {{Name: "collection-1.wt", Size: 100}, {Name: "index-1.wt", Size: 20}},
+	})
+	saveBackup(&backup.BackupMeta{Name: "inc1", Type: defs.IncrementalBackup, Status: defs.StatusDone, SrcBackup: "base"}, map[string]backup.Filelist{
+		"rs0": {{Name: "collection-1.wt", Off: 90, Len: 60, Size: 150}, {Name: "collection-2.wt", Size: 30}},
+	})
+
+	tests := map[string]struct {
+		name     string
+		expected map[string]int64
+		err      bool
+	}{
+		"physical": {
+			name:     "full",
+			expected: map[string]int64{"rs0": 120, "rs1": 50},
+		},
+		"incremental chain": {
+			name:     "inc1",
+			expected: map[string]int64{"rs0": 200},
+		},
+		"missing backup": {
+			name: "missing",
+			err:  true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sizes, err := ReplsetDataSizes(stg, tt.name)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sizes, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, sizes)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package backup
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/mongo"
+)
+
+const (
+	defaultMemberPriority = 1.0
+	primaryMemberPriority = 0.5
+	memberTagPodName      = "podName"
+)
+
+// getRulesPriorities translates priority rules into priorities of all replset members.
+// Every member gets a priority, otherwise PBM would assign 1.0 to members missing in the config.
+func getRulesPriorities(ctx context.Context, k8sclient client.Client, cluster *api.PerconaServerMongoDB, rules []api.BackupPriorityRule) (map[string]float64, error) {
+	log := logf.FromContext(ctx)
+	priorities := make(map[string]float64)
+
+	c, err := backupCredentials(ctx, k8sclient, cluster)
+	if err != nil {
+		return priorities, err
+	}
+
+	repls := cluster.Spec.Replsets
+	if cluster.Spec.Sharding.Enabled && cluster.Spec.Sharding.ConfigsvrReplSet != nil {
+		repls = append([]*api.ReplsetSpec{cluster.Spec.Sharding.ConfigsvrReplSet}, repls...)
+	}
+
+	for _, rs := range repls {
+		cli, err := psmdb.MongoClient(ctx, k8sclient, cluster, rs, c)
+		if err != nil {
+			return priorities, errors.Wrap(err, "get mongo client")
+		}
+
+		cfg, err := cli.ReadConfig(ctx)
+		if err != nil {
+			_ = cli.Disconnect(ctx)
+			return priorities, errors.Wrapf(err, "read replset %s config", rs.Name)
+		}
+
+		status, err := cli.RSStatus(ctx)
+
+		if disconnectErr := cli.Disconnect(ctx); disconnectErr != nil {
+			log.Error(disconnectErr, "failed to close connection to replicaSet", "rs", rs.Name)
+		}
+
+		if err != nil {
+			return priorities, errors.Wrapf(err, "get replset %s status", rs.Name)
+		}
+
+		primary := ""
+		if p := status.Primary(); p != nil {
+			primary = p.Name
+		}
+
+		pods, err := psmdb.GetRSPods(ctx, k8sclient, cluster, rs.Name)
+		if err != nil {
+			return priorities, errors.Wrapf(err, "get replset %s pods", rs.Name)
+		}
+
+		podLabels := make(map[string]map[string]string, len(pods.Items))
+		for _, pod := range pods.Items {
+			podLabels[pod.Name] = pod.Labels
+		}
+
+		rsPriorities, err := memberPriorities(rules, cfg.Members, primary, podLabels)
+		if err != nil {
+			return priorities, errors.Wrapf(err, "get replset %s priorities", rs.Name)
+		}
+
+		for host, p := range rsPriorities {
+			priorities[host] = p
+		}
+	}
+
+	return priorities, nil
+}
+
+// memberPriorities returns PBM priorities of the replset members.
+// podLabels contains labels of member pods by their names.
+func memberPriorities(
+	rules []api.BackupPriorityRule,
+	members mongo.ConfigMembers,
+	primary string,
+	podLabels map[string]map[string]string,
+) (map[string]float64, error) {
+	selectors := make([]labels.Selector, len(rules))
+	for i, rule := range rules {
+		if rule.Selector == nil {
+			continue
+		}
+
+		sel, err := metav1.LabelSelectorAsSelector(rule.Selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse selector of rule %d", i)
+		}
+		selectors[i] = sel
+	}
+
+	priorities := make(map[string]float64, len(members))
+	for _, m := range members {
+		// PBM agents don't run on arbiters
+		if m.ArbiterOnly {
+			continue
+		}
+
+		p := defaultMemberPriority
+		if m.Host == primary {
+			p = primaryMemberPriority
+		}
+
+		for i, rule := range rules {
+			if matchMember(rule, selectors[i], m, primary, podLabels) {
+				p = rule.Priority
+				break
+			}
+		}
+
+		priorities[m.Host] = p
+	}
+
+	return priorities, nil
+}
+
+func matchMember(rule api.BackupPriorityRule, sel labels.Selector, m mongo.ConfigMember, primary string, podLabels map[string]map[string]string) bool {
+	switch rule.Role {
+	case "":
+	case api.BackupMemberRolePrimary:
+		if m.Host != primary {
+			return false
+		}
+	case api.BackupMemberRoleSecondary:
+		if m.Host == primary || m.Hidden || m.Votes == 0 {
+			return false
+		}
+	case api.BackupMemberRoleHidden:
+		if !m.Hidden {
+			return false
+		}
+	case api.BackupMemberRoleNonVoting:
+		if m.Votes != 0 {
+			return false
+		}
+	default:
+		return false
+	}
+
+	for k, v := range rule.Tags {
+		if m.Tags[k] != v {
+			return false
+		}
+	}
+
+	if sel != nil {
+		lbls, ok := podLabels[m.Tags[memberTagPodName]]
+		if !ok || !sel.Matches(labels.Set(lbls)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,89 @@
+package backup
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/psmdb/mongo"
+)
+
+func TestMemberPriorities(t *testing.T) {
+	members := mongo.ConfigMembers{
+		{Host: "rs0-0:27017", Votes: 1, Tags: mongo.ReplsetTags{"podName": "rs0-0", "zone": "a"}},
+		{Host: "rs0-1:27017", Votes: 1, Tags: mongo.ReplsetTags{"podName": "rs0-1", "zone": "b"}},
+		{Host: "rs0-2:27017", Votes: 1, Tags: mongo.ReplsetTags{"podName": "rs0-2", "zone": "b"}},
+		{Host: "rs0-nv-0:27017", Votes: 0, Tags: mongo.ReplsetTags{"podName": "rs0-nv-0", "nonVoting": "true", "zone": "a"}},
+		{Host: "rs0-arbiter-0:27017", Votes: 1, ArbiterOnly: true},
+		{Host: "ext:27017", Votes: 0, Hidden: true, Tags: mongo.ReplsetTags{"external": "true"}},
+	}
+	podLabels := map[string]map[string]string{
+		"rs0-0":    {"app.kubernetes.io/component": "mongod", "backup": "preferred"},
+		"rs0-1":    {"app.kubernetes.io/component": "mongod"},
+		"rs0-2":    {"app.kubernetes.io/component": "mongod"},
+		"rs0-nv-0": {"app.kubernetes.io/component": "nonVoting"},
+	}
+	primary := "rs0-1:27017"
+
+	tests := map[string]struct {
+		rules    []api.BackupPriorityRule
+		expected map[string]float64
+	}{
+		"no matching rules": {
+			rules: []api.BackupPriorityRule{
+				{Role: api.BackupMemberRoleHidden, Tags: map[string]string{"zone": "c"}, Priority: 3},
+			},
+			expected: map[string]float64{
+				"rs0-0:27017":    1,
+				"rs0-1:27017":    0.5,
+				"rs0-2:27017":    1,
+				"rs0-nv-0:27017": 1,
+				"ext:27017":      1,
+			},
+		},
+		"roles": {
+			rules: []api.BackupPriorityRule{
+				{Role: api.BackupMemberRoleNonVoting, Priority: 3},
+				{Role: api.BackupMemberRoleHidden, Priority: 2.5},
+				{Role: api.BackupMemberRolePrimary, Priority: 0.1},
+			},
+			expected: map[string]float64{
+				"rs0-0:27017":    1,
+				"rs0-1:27017":    0.1,
+				"rs0-2:27017":    1,
+				"rs0-nv-0:27017": 3,
+				"ext:27017":      3,
+			},
+		},
+		"selector and zone": {
+			rules: []api.BackupPriorityRule{
+				{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"backup": "preferred"}},
+					Priority: 3,
+				},
+				{Role: api.BackupMemberRoleSecondary, Tags: map[string]string{"zone": "b"}, Priority: 2},
+			},
+			expected: map[string]float64{
+				"rs0-0:27017":    3,
+				"rs0-1:27017":    0.5,
+				"rs0-2:27017":    2,
+				"rs0-nv-0:27017": 1,
+				"ext:27017":      1,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			priorities, err := memberPriorities(tt.rules, members, primary, podLabels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(priorities, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, priorities)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package psmdb
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+)
+
+// BackupStoragePVCName returns the name of the PVC the operator creates for the filesystem storage.
+func BackupStoragePVCName(cr *api.PerconaServerMongoDB, storageName string) string {
+	return cr.Name + "-backup-" + storageName
+}
+
+func backupStorageVolumeName(storageName string) string {
+	return "backup-storage-" + storageName
+}
+
+// backupStoragesWithPVC returns sorted names of filesystem storages
+// that have the volume provisioned by the operator.
+func backupStoragesWithPVC(cr *api.PerconaServerMongoDB) []string {
+	var names []string
+	for name, stg := range cr.Spec.Backup.Storages {
+		if stg.Type == api.BackupStorageFilesystem && stg.Filesystem.PersistentVolumeClaim != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// BackupStoragePVCs returns PVCs for filesystem storages with spec.backup.storages.<name>.filesystem.persistentVolumeClaim.
+func BackupStoragePVCs(cr *api.PerconaServerMongoDB) []corev1.PersistentVolumeClaim {
+	names := backupStoragesWithPVC(cr)
+
+	pvcs := make([]corev1.PersistentVolumeClaim, 0, len(names))
+	for _, name := range names {
+		pvcs = append(pvcs, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      BackupStoragePVCName(cr, name),
+				Namespace: cr.Namespace,
+				Labels:    naming.BackupStorageLabels(cr),
+			},
+			Spec: *cr.Spec.Backup.Storages[name].Filesystem.PersistentVolumeClaim.DeepCopy(),
+		})
+	}
+
+	return pvcs
+}
+
+// BackupStorageVolumes returns volumes and volume mounts for filesystem storages
+// with the volume provisioned by the operator. Volumes are mounted at the storage path.
+func BackupStorageVolumes(cr *api.PerconaServerMongoDB) ([]corev1.Volume, []corev1.VolumeMount) {
+	names := backupStoragesWithPVC(cr)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	volumes := make([]corev1.Volume, 0, len(names))
+	mounts := make([]corev1.VolumeMount, 0, len(names))
+	for _, name := range names {
+		volumes = append(volumes, corev1.Volume{
+			Name: backupStorageVolumeName(name),
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: BackupStoragePVCName(cr, name),
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      backupStorageVolumeName(name),
+			MountPath: cr.Spec.Backup.Storages[name].Filesystem.Path,
+		})
+	}
+
+	return volumes, mounts
+}
@@ -0,0 +1,186 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+)
+
+const namespace = "psmdb"
+
+var (
+	backupLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "backup",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Completion time of the last successful backup.",
+	}, []string{"namespace", "cluster", "storage"})
+
+	backupDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "backup",
+		Name:      "last_duration_seconds",
+		Help:      "Duration of the last successful backup.",
+	}, []string{"namespace", "cluster", "storage"})
+
+	backupSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "backup",
+		Name:      "last_size_bytes",
+		Help:      "Size of the last successful backup in the storage.",
+	}, []string{"namespace", "cluster", "storage"})
+
+	backupFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "backup",
+		Name:      "failures_total",
+		Help:      "Number of failed backups. The task label is empty for backups that are not created by a scheduled task.",
+	}, []string{"namespace", "cluster", "storage", "task"})
+
+	restoreDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "restore",
+		Name:      "last_duration_seconds",
+		Help:      "Duration of the last finished restore.",
+	}, []string{"namespace", "cluster", "state"})
+
+	restores = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "restore",
+		Name:      "total",
+		Help:      "Number of finished restores by their final state.",
+	}, []string{"namespace", "cluster", "state"})
+
+	pitrLag = &pitrLagCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "pitr", "lag_seconds"),
+			"Time passed since the latest restorable time of point-in-time recovery.",
+			[]string{"namespace", "cluster"}, nil,
+		),
+		latest: make(map[clusterKey]time.Time),
+		now:    time.Now,
+	}
+)
+
+type clusterKey struct {
+	namespace string
+	cluster   string
+}
+
+// pitrLagCollector reports the PITR lag calculated at scrape time,
+// so the lag keeps growing between reconciles if oplog isn't saved.
+type pitrLagCollector struct {
+	desc *prometheus.Desc
+	now  func() time.Time
+
+	mu     sync.Mutex
+	latest map[clusterKey]time.Time
+}
+
+func (c *pitrLagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *pitrLagCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, t := range c.latest {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, now.Sub(t).Seconds(), k.namespace, k.cluster)
+	}
+}
+
+func (c *pitrLagCollector) set(k clusterKey, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.latest[k] = t
+}
+
+func (c *pitrLagCollector) delete(k clusterKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.latest, k)
+}
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		backupLastSuccess,
+		backupDuration,
+		backupSize,
+		backupFailures,
+		restoreDuration,
+		restores,
+		pitrLag,
+	)
+}
+
+// ObserveBackup updates backup metrics. It should be called once
+// when the backup reaches the ready or error state.
+func ObserveBackup(cr *api.PerconaServerMongoDBBackup) {
+	cluster := cr.Spec.GetClusterName()
+
+	switch cr.Status.State {
+	case api.BackupStateReady:
+		completed := time.Now()
+		if cr.Status.CompletedAt != nil {
+			completed = cr.Status.CompletedAt.Time
+		}
+		backupLastSuccess.WithLabelValues(cr.Namespace, cluster, cr.Spec.StorageName).Set(float64(completed.Unix()))
+
+		if cr.Status.Duration != nil {
+			backupDuration.WithLabelValues(cr.Namespace, cluster, cr.Spec.StorageName).Set(cr.Status.Duration.Seconds())
+		}
+		if cr.Status.SizeBytes > 0 {
+			backupSize.WithLabelValues(cr.Namespace, cluster, cr.Spec.StorageName).Set(float64(cr.Status.SizeBytes))
+		}
+	case api.BackupStateError:
+		task := cr.Labels[naming.LabelBackupAncestor]
+		backupFailures.WithLabelValues(cr.Namespace, cluster, cr.Spec.StorageName, task).Inc()
+	}
+}
+
+// ObserveRestore updates restore metrics. It should be called once
+// when the restore reaches the ready or error state.
+func ObserveRestore(cr *api.PerconaServerMongoDBRestore) {
+	if cr.Spec.DryRun || cr.Status.State != api.RestoreStateReady && cr.Status.State != api.RestoreStateError {
+		return
+	}
+
+	completed := time.Now()
+	if cr.Status.CompletedAt != nil {
+		completed = cr.Status.CompletedAt.Time
+	}
+	state := string(cr.Status.State)
+
+	restoreDuration.WithLabelValues(cr.Namespace, cr.Spec.ClusterName, state).Set(completed.Sub(cr.CreationTimestamp.Time).Seconds())
+	restores.WithLabelValues(cr.Namespace, cr.Spec.ClusterName, state).Inc()
+}
+
+// SetPITRLatestRestorableTime sets the latest restorable time of the cluster
+// the PITR lag is reported from.
+func SetPITRLatestRestorableTime(namespace, cluster string, latestRestorable time.Time) {
+	pitrLag.set(clusterKey{namespace, cluster}, latestRestorable)
+}
+
+// DeletePITR removes the PITR metrics of the cluster. It should be called
+// when PITR is disabled.
+func DeletePITR(namespace, cluster string) {
+	pitrLag.delete(clusterKey{namespace, cluster})
+}
+
+// DeleteCluster removes all metrics of the cluster.
+func DeleteCluster(namespace, cluster string) {
+	labels := prometheus.Labels{"namespace": namespace, "cluster": cluster}
+	for _, m := range []interface {
+		DeletePartialMatch(prometheus.Labels) int
+	}{backupLastSuccess, backupDuration, backupSize, backupFailures, restoreDuration, restores} {
+		m.DeletePartialMatch(labels)
+	}
+	DeletePITR(namespace, cluster)
+}
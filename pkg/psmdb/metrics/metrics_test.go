@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/percona/percona-server-mongodb-operator/pkg/apis/psmdb/v1"
+	"github.com/percona/percona-server-mongodb-operator/pkg/naming"
+)
+
+func TestObserveBackup(t *testing.T) {
+	completed := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	bcp := &api.PerconaServerMongoDBBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "ns",
+			Labels:    map[string]string{naming.LabelBackupAncestor: "daily"},
+		},
+		Spec: api.PerconaServerMongoDBBackupSpec{
+			ClusterName: "cluster",
+			StorageName: "s3",
+		},
+		Status: api.PerconaServerMongoDBBackupStatus{
+			State:       api.BackupStateReady,
+			CompletedAt: &metav1.Time{Time: completed},
+			Duration:    &metav1.Duration{Duration: 90 * time.Second},
+			SizeBytes:   1024,
+		},
+	}
+	ObserveBackup(bcp)
+
+	if v := testutil.ToFloat64(backupLastSuccess.WithLabelValues("ns", "cluster", "s3")); v != float64(completed.Unix()) {
+		t.Errorf("unexpected last success time %f", v)
+	}
+	if v := testutil.ToFloat64(backupDuration.WithLabelValues("ns", "cluster", "s3")); v != 90 {
+		t.Errorf("unexpected duration %f", v)
+	}
+	if v := testutil.ToFloat64(backupSize.WithLabelValues("ns", "cluster", "s3")); v != 1024 {
+		t.Errorf("unexpected size %f", v)
+	}
+
+	bcp.Status.State = api.BackupStateError
+	ObserveBackup(bcp)
+	ObserveBackup(bcp)
+
+	if v := testutil.ToFloat64(backupFailures.WithLabelValues("ns", "cluster", "s3", "daily")); v != 2 {
+		t.Errorf("unexpected failures %f", v)
+	}
+
+	DeleteCluster("ns", "cluster")
+
+	if n := testutil.CollectAndCount(backupFailures); n != 0 {
+		t.Errorf("expected no failures metrics after cluster deletion, got %d", n)
+	}
+}
+
+func TestPITRLag(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	pitrLag.now = func() time.Time { return now }
+	defer func() { pitrLag.now = time.Now }()
+
+	SetPITRLatestRestorableTime("ns", "cluster", now.Add(-time.Minute))
+	if v := testutil.ToFloat64(pitrLag); v != 60 {
+		t.Errorf("unexpected lag %f", v)
+	}
+
+	now = now.Add(time.Minute)
+	if v := testutil.ToFloat64(pitrLag); v != 120 {
+		t.Errorf("lag is not updated between reconciles: %f", v)
+	}
+
+	DeletePITR("ns", "cluster")
+	if n := testutil.CollectAndCount(pitrLag); n != 0 {
+		t.Errorf("expected no lag metrics after PITR is disabled, got %d", n)
+	}
+
+	SetPITRLatestRestorableTime("ns", "cluster", now)
+	DeleteCluster("ns", "cluster")
+	if n := testutil.CollectAndCount(pitrLag); n != 0 {
+		t.Errorf("expected no lag metrics after cluster deletion, got %d", n)
+	}
+}
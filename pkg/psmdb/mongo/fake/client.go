@@ -149,3 +149,15 @@ func (c *fakeMongoClient) UpdateUserPass(ctx context.Context, db, name, pass str
 func (c *fakeMongoClient) UpdateUser(ctx context.Context, currName, newName, pass string) error {
 	return nil
 }
+
+func (c *fakeMongoClient) ListCollections(ctx context.Context, db string) ([]string, error) {
+	return nil, nil
+}
+
+func (c *fakeMongoClient) ValidateCollection(ctx context.Context, db, coll string) (*mongo.ValidateResp, error) {
+	return nil, nil
+}
+
+func (c *fakeMongoClient) DBHash(ctx context.Context, db string) (*mongo.DBHashResp, error) {
+	return nil, nil
+}
@@ -95,6 +95,23 @@ type DBList struct {
 	OKResponse `bson:",inline"`
 }
 
+// ValidateResp document from 'validate': https://www.mongodb.com/docs/manual/reference/command/validate/
+type ValidateResp struct {
+	NS         string   `bson:"ns" json:"ns"`
+	NRecords   int64    `bson:"nrecords" json:"nrecords"`
+	Valid      bool     `bson:"valid" json:"valid"`
+	Errors     []string `bson:"errors" json:"errors"`
+	Warnings   []string `bson:"warnings" json:"warnings"`
+	OKResponse `bson:",inline"`
+}
+
+// DBHashResp document from 'dbHash': https://www.mongodb.com/docs/manual/reference/command/dbHash/
+type DBHashResp struct {
+	Collections map[string]string `bson:"collections" json:"collections"`
+	MD5         string            `bson:"md5" json:"md5"`
+	OKResponse  `bson:",inline"`
+}
+
 type ShardList struct {
 	Shards []struct {
 		ID    string `json:"_id" bson:"_id"`
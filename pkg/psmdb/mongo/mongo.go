@@ -57,6 +57,9 @@ type Client interface {
 	UpdateUserRoles(ctx context.Context, db, username string, roles []map[string]interface{}) error
 	UpdateUserPass(ctx context.Context, db, name, pass string) error
 	UpdateUser(ctx context.Context, currName, newName, pass string) error
+	ListCollections(ctx context.Context, db string) ([]string, error)
+	ValidateCollection(ctx context.Context, db, coll string) (*ValidateResp, error)
+	DBHash(ctx context.Context, db string) (*DBHashResp, error)
 }
 
 type ClientDatabase interface {
@@ -676,6 +679,55 @@ func (client *mongoClient) UpdateUser(ctx context.Context, currName, newName, pa
 	return errors.Wrap(err, "drop user")
 }
 
+// ListCollections returns names of the collections in the given database.
+// Views and system buckets are not included.
+func (client *mongoClient) ListCollections(ctx context.Context, db string) ([]string, error) {
+	names, err := client.Client.Database(db).ListCollectionNames(ctx, bson.D{{Key: "type", Value: "collection"}})
+	if err != nil {
+		return nil, errors.Wrap(err, "listCollections")
+	}
+
+	return names, nil
+}
+
+func (client *mongoClient) ValidateCollection(ctx context.Context, db, coll string) (*ValidateResp, error) {
+	resp := ValidateResp{}
+
+	res := client.Database(db).RunCommand(ctx, bson.D{{Key: "validate", Value: coll}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "validate")
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode validate response")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return &resp, nil
+}
+
+func (client *mongoClient) DBHash(ctx context.Context, db string) (*DBHashResp, error) {
+	resp := DBHashResp{}
+
+	res := client.Database(db).RunCommand(ctx, bson.D{{Key: "dbHash", Value: 1}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "dbHash")
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode dbHash response")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return &resp, nil
+}
+
 // RemoveOld removes from the list those members which are not present in the given list.
 // It always should leave at least one element. The config won't be valid for mongo otherwise.
 // Better, if the last element has the smallest ID in order not to produce defragmentation
@@ -260,6 +260,9 @@ func StatefulSpec(ctx context.Context, cr *api.PerconaServerMongoDB, replset *ap
 				rsName = name
 			}
 			containers = append(containers, backupAgentContainer(cr, rsName, cr.TLSEnabled()))
+
+			stgVolumes, _ := BackupStorageVolumes(cr)
+			volumes = append(volumes, stgVolumes...)
 		}
 
 		pmmC := AddPMMContainer(ctx, cr, usersSecret, cr.Spec.PMM.MongodParams)
@@ -439,6 +442,9 @@ func backupAgentContainer(cr *api.PerconaServerMongoDB, replsetName string, tlsE
 		c.VolumeMounts = append(c.VolumeMounts, cr.Spec.Backup.VolumeMounts...)
 	}
 
+	_, stgMounts := BackupStorageVolumes(cr)
+	c.VolumeMounts = append(c.VolumeMounts, stgMounts...)
+
 	return c
 }
 